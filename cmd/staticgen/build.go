@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+
+	"github.com/blamarvt/staticgen/pkg/site"
+)
+
+func runBuild(args []string) {
+	flags := flag.NewFlagSet("build", flag.ExitOnError)
+	layout := addLayoutFlags(flags)
+	cacheFile := flags.String("cache", ".staticgen-cache.json", "build cache used to skip unchanged pages (empty to disable)")
+	force := flags.Bool("force", false, "regenerate every page, ignoring the build cache")
+	jobs := flags.Int("j", runtime.GOMAXPROCS(0), "number of pages to generate concurrently")
+	drafts := flags.Bool("drafts", false, "also build pages marked as drafts")
+	failFast := flags.Bool("fail-fast", false, "stop at the first page that fails instead of reporting every failure")
+	setUsage(flags, "Generates every page into the output directory.")
+	flags.Parse(args)
+
+	opts, err := layout.options()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	opts.CacheFile = *cacheFile
+	opts.Force = *force
+	opts.Jobs = *jobs
+	opts.FailFast = *failFast
+	opts.Drafts = *drafts
+
+	result, err := site.Build(opts)
+	if err != nil {
+		// Print errors without the log prefix so file:line:col locations
+		// are picked up by editors and CI annotations
+		fmt.Fprintln(os.Stderr, err)
+
+		var failures site.Errors
+		if errors.As(err, &failures) {
+			fmt.Fprintf(os.Stderr, "\nBuild failed: %s in %d of %d pages\n", failures.Summary(), result.Failed, result.Total())
+		}
+		os.Exit(1)
+	}
+
+	log.Printf("Generated %d pages (%d unchanged)", result.Generated, result.Unchanged)
+	if copied, removed := result.AssetsCopied, result.AssetsRemoved; copied+removed+result.AssetsUnchanged > 0 {
+		log.Printf("Copied %d static assets (%d unchanged, %d removed)", copied, result.AssetsUnchanged, removed)
+	}
+}
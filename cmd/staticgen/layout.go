@@ -0,0 +1,97 @@
+package main
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/blamarvt/staticgen/pkg/site"
+	"github.com/blamarvt/staticgen/pkg/vars"
+)
+
+// layoutFlags are the flags that override the project layout from the config
+// file. Only flags given on the command line take effect.
+type layoutFlags struct {
+	flags *flag.FlagSet
+
+	configFile          *string
+	components          *string
+	pages               *string
+	output              *string
+	static              *string
+	data                *string
+	pageExtensions      *string
+	componentExtensions *string
+	fingerprint         *string
+	baseURL             *string
+	pageLayout          *string
+}
+
+// addLayoutFlags registers the config and layout flags on flags
+func addLayoutFlags(flags *flag.FlagSet) *layoutFlags {
+	defaults := site.DefaultOptions()
+	return &layoutFlags{
+		flags:               flags,
+		configFile:          flags.String("config", ".staticgen.yml", "configuration file"),
+		components:          flags.String("components", strings.Join(defaults.ComponentsDirs, ","), "comma-separated component directories"),
+		pages:               flags.String("pages", defaults.PagesDir, "pages directory"),
+		output:              flags.String("output", defaults.OutputDir, "output directory for generated pages"),
+		static:              flags.String("static", defaults.StaticDir, "static assets directory"),
+		data:                flags.String("data", defaults.DataDir, "data files directory"),
+		pageExtensions:      flags.String("page-ext", strings.Join(defaults.PageExtensions, ","), "comma-separated page file extensions"),
+		componentExtensions: flags.String("component-ext", strings.Join(defaults.ComponentExtensions, ","), "comma-separated component file extensions"),
+		fingerprint:         flags.String("fingerprint", "", "comma-separated extensions of static assets to fingerprint, e.g. .css,.js"),
+		baseURL:             flags.String("base-url", "", "absolute URL the site is published at, used by the sitemap"),
+		pageLayout:          flags.String("layout", "", "layout component wrapping pages that don't choose one"),
+	}
+}
+
+// options resolves the site options: flags given on the command line
+// override the config file, which overrides the defaults
+func (l *layoutFlags) options() (site.Options, error) {
+	opts, err := site.LoadOptions(*l.configFile)
+	if err != nil {
+		return opts, err
+	}
+
+	var layout vars.Layout
+	l.flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "components":
+			layout.Components = splitList(*l.components)
+		case "pages":
+			layout.Pages = *l.pages
+		case "output":
+			layout.Output = *l.output
+		case "static":
+			layout.Static = *l.static
+		case "data":
+			layout.Data = *l.data
+		case "page-ext":
+			layout.PageExtensions = splitList(*l.pageExtensions)
+		case "component-ext":
+			layout.ComponentExtensions = splitList(*l.componentExtensions)
+		case "fingerprint":
+			layout.Fingerprint = splitList(*l.fingerprint)
+		case "base-url":
+			opts.BaseURL = *l.baseURL
+		case "layout":
+			// An empty -layout "" clears a layout set in the config file
+			opts.Layout = *l.pageLayout
+		}
+	})
+
+	// Command line paths are relative to the working directory
+	opts.ApplyLayout(layout, ".")
+	return opts, nil
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(value string) []string {
+	var list []string
+	for item := range strings.SplitSeq(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -2,74 +2,30 @@ package main
 
 import (
 	"flag"
-	"log"
+	"fmt"
 	"os"
-	"path/filepath"
-
-	"github.com/pkg/errors"
-
-	"github.com/blamarvt/staticgen/pkg/component"
-	"github.com/blamarvt/staticgen/pkg/page"
-	"github.com/blamarvt/staticgen/pkg/vars"
 )
 
 func main() {
-	outputDir := flag.String("output", "dist", "output directory for generated pages")
-	configFile := flag.String("config", ".staticgen.yml", "configuration file")
-	flag.Parse()
+	args := os.Args[1:]
 
-	registry := component.NewRegistry()
-	if err := registry.LoadAll("templates"); err != nil {
-		log.Fatal(errors.Wrap(err, "loading components"))
+	command := "build"
+	if len(args) > 0 && (args[0] == "build" || args[0] == "serve") {
+		command, args = args[0], args[1:]
 	}
 
-	// Create a variables store and load from config
-	variables := vars.NewStore()
-	if config, err := vars.LoadConfig(*configFile); err == nil {
-		variables.LoadFromConfig(config)
-	} else if !os.IsNotExist(err) {
-		// Only log if the error is not "file not found"
-		log.Printf("Warning: failed to load config file: %v", err)
+	switch command {
+	case "serve":
+		runServe(args)
+	default:
+		runBuild(args)
 	}
+}
 
-	pagesDir := "pages"
-	err := filepath.WalkDir(pagesDir, func(path string, d os.DirEntry, err error) error {
-		if err != nil || d.IsDir() || filepath.Ext(path) != ".hcml" {
-			return errors.Wrap(err, "walking pages directory")
-		}
-
-		p, err := page.LoadPage(path, registry)
-		if err != nil {
-			return errors.Wrap(err, "loading page "+path)
-		}
-
-		html, err := page.Generate(p, registry, variables)
-		if err != nil {
-			return errors.Wrap(err, "generating page "+path)
-		}
-
-		// Use the path attribute from the page, or derive from file location
-		var outPath string
-		if p.Path != "" {
-			outPath = filepath.Join(*outputDir, filepath.FromSlash(p.Path))
-		} else {
-			// Derive output path from source file location relative to pages dir
-			relPath, err := filepath.Rel(pagesDir, path)
-			if err != nil {
-				return errors.Wrap(err, "getting relative path for "+path)
-			}
-			// Replace .hcml extension with .html
-			outPath = filepath.Join(*outputDir, relPath[:len(relPath)-len(filepath.Ext(relPath))]+".html")
-		}
-
-		if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
-			return errors.Wrap(err, "creating directories for "+outPath)
-		}
-
-		return os.WriteFile(outPath, []byte(html), 0644)
-	})
-
-	if err != nil {
-		log.Fatal(errors.Wrap(err, "walking pages directory"))
+// setUsage describes a subcommand and its flags in the -h output
+func setUsage(flags *flag.FlagSet, description string) {
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: staticgen %s [flags]\n\n%s\n\nFlags:\n", flags.Name(), description)
+		flags.PrintDefaults()
 	}
 }
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+
+	"github.com/blamarvt/staticgen/pkg/devserver"
+	"github.com/blamarvt/staticgen/pkg/site"
+)
+
+func runServe(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := flags.String("addr", "localhost:8080", "address to listen on")
+	layout := addLayoutFlags(flags)
+	setUsage(flags, "Serves the site over HTTP, rebuilding and reloading open browsers on every change.")
+	flags.Parse(args)
+
+	opts, err := serveOptions(layout)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// The output directory is ignored: the server builds into a directory of its own
+	var server *devserver.Server
+	server = devserver.New(func(outputDir string) error {
+		// The config file is read again on every rebuild, so edits to the
+		// layout, libraries or feeds take effect without a restart
+		opts, err := serveOptions(layout)
+		if err != nil {
+			return err
+		}
+		server.SetWatch(opts.WatchPaths()...)
+
+		opts.OutputDir = outputDir
+		_, err = site.Build(opts)
+		return err
+	}, opts.WatchPaths()...)
+	defer server.Close()
+
+	// A broken initial build still starts the server so the next fix is picked up
+	if err := server.Rebuild(); err != nil {
+		log.Printf("Build failed: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	go server.Watch(ctx)
+
+	httpServer := &http.Server{Addr: *addr, Handler: server}
+	go func() {
+		<-ctx.Done()
+		httpServer.Close()
+	}()
+
+	log.Printf("Serving on http://%s", *addr)
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+}
+
+// serveOptions resolves the site options for the development server
+func serveOptions(layout *layoutFlags) (site.Options, error) {
+	opts, err := layout.options()
+	// Drafts are previewed while writing them
+	opts.Drafts = true
+	return opts, err
+}
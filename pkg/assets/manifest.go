@@ -0,0 +1,59 @@
+package assets
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// ManifestFile is the name of the asset manifest written to the output directory
+const ManifestFile = "asset-manifest.json"
+
+// Manifest maps the path of every static asset, relative to the static
+// directory, to its path in the output directory. Both are slash-separated.
+type Manifest map[string]string
+
+// AssetURL returns the root-relative URL a static asset is served from.
+// Names may start with a slash.
+func (m Manifest) AssetURL(name string) (string, error) {
+	out, ok := m[strings.TrimPrefix(name, "/")]
+	if !ok {
+		return "", fmt.Errorf("unknown static asset %q", name)
+	}
+	return "/" + out, nil
+}
+
+// Write saves the manifest as JSON, with keys sorted so the file is identical
+// between builds
+func (m Manifest) Write(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// ReadManifest loads a manifest written by Write
+func ReadManifest(path string) (Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Files returns the output path of every asset in the manifest, sorted
+func (m Manifest) Files() []string {
+	files := make([]string, 0, len(m))
+	for _, out := range m {
+		files = append(files, out)
+	}
+	slices.Sort(files)
+	return files
+}
@@ -0,0 +1,170 @@
+package assets
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/blamarvt/staticgen/pkg/buildcache"
+)
+
+// SyncResult summarizes a static directory sync
+type SyncResult struct {
+	Files     []string // Every file now mirrored, slash-separated and relative to the output directory
+	Manifest  Manifest // Output path of every file by its path in the static directory
+	Copied    int      // Files copied because they were new or changed
+	Unchanged int      // Files skipped because the output copy is up to date
+	Removed   int      // Files removed because they no longer exist in the static directory
+}
+
+// Sync mirrors every file under srcDir into outDir, preserving the directory
+// structure. Files with one of the fingerprint extensions are renamed to
+// include a hash of their contents. Files whose output copy has the same size
+// and modification time are skipped. Files listed in previous, the Files of
+// the last sync, that are no longer produced are removed from outDir. A
+// missing srcDir is treated as empty.
+func Sync(srcDir, outDir string, previous []string, fingerprint []string) (*SyncResult, error) {
+	result := &SyncResult{Manifest: make(Manifest)}
+
+	err := filepath.WalkDir(srcDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == srcDir && os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+
+		out := name
+		if slices.Contains(fingerprint, filepath.Ext(name)) {
+			hash, err := buildcache.HashFile(path)
+			if err != nil {
+				return err
+			}
+			out = Fingerprint(name, hash)
+		}
+		result.Files = append(result.Files, out)
+		result.Manifest[name] = out
+
+		copied, err := copyIfChanged(path, filepath.Join(outDir, filepath.FromSlash(out)))
+		if err != nil {
+			return errors.Wrap(err, "copying static asset "+path)
+		}
+		if copied {
+			result.Copied++
+		} else {
+			result.Unchanged++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	current := make(map[string]bool, len(result.Files))
+	for _, rel := range result.Files {
+		current[rel] = true
+	}
+	for _, rel := range previous {
+		if current[rel] {
+			continue
+		}
+		removed, err := remove(outDir, rel)
+		if err != nil {
+			return nil, errors.Wrap(err, "removing static asset "+rel)
+		}
+		if removed {
+			result.Removed++
+		}
+	}
+
+	return result, nil
+}
+
+// Fingerprint inserts the first characters of a content hash before the
+// extension of name: "css/app.css" becomes "css/app.3f9a1c2b.css"
+func Fingerprint(name, hash string) string {
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + hash[:min(len(hash), fingerprintLength)] + ext
+}
+
+// fingerprintLength is the number of hash characters in fingerprinted names
+const fingerprintLength = 8
+
+// copyIfChanged copies src to dst unless dst already has the same size and
+// modification time. The copy is given src's modification time so the next
+// sync can skip it.
+func copyIfChanged(src, dst string) (bool, error) {
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return false, err
+	}
+	if dstInfo, err := os.Stat(dst); err == nil && dstInfo.Size() == srcInfo.Size() && dstInfo.ModTime().Equal(srcInfo.ModTime()) {
+		return false, nil
+	}
+
+	if err := copyFile(src, dst); err != nil {
+		return false, err
+	}
+	return true, os.Chtimes(dst, srcInfo.ModTime(), srcInfo.ModTime())
+}
+
+// copyFile copies the contents of src to dst, creating dst's directory
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// remove deletes the file rel from outDir along with any directories the
+// removal left empty. It reports whether the file existed.
+func remove(outDir, rel string) (bool, error) {
+	// Previous files may come from a manifest on disk; never leave outDir
+	if !filepath.IsLocal(filepath.FromSlash(rel)) {
+		return false, nil
+	}
+	path := filepath.Join(outDir, filepath.FromSlash(rel))
+	if err := os.Remove(path); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	// Removing a non-empty directory fails, which ends the walk up
+	for dir := filepath.Dir(path); dir != filepath.Clean(outDir); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return true, nil
+}
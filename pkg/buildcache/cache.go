@@ -0,0 +1,103 @@
+package buildcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"maps"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// Version identifies the cache file format. Caches written with a different
+// version are discarded.
+const Version = 2
+
+// Cache records what every generated page depended on during the previous
+// build, keyed by output path, and which static assets were copied
+type Cache struct {
+	Version int               `json:"version"`
+	Pages   map[string]*Entry `json:"pages"`
+	Assets  []string          `json:"assets,omitempty"` // Static files copied, relative to the output directory
+}
+
+// Entry is the dependency record for a single generated page. Each map holds
+// the content hash of a dependency at the time the page was generated.
+type Entry struct {
+	Source     string            `json:"source"`
+	Files      map[string]string `json:"files"`            // File path -> content hash
+	Components map[string]string `json:"components"`       // Component name -> definition checksum
+	Variables  map[string]string `json:"variables"`        // Variable name -> value hash
+	Assets     map[string]string `json:"assets,omitempty"` // Static asset name -> output path it resolved to
+	Layout     string            `json:"layout,omitempty"` // Qualified name of the layout the page was wrapped in
+}
+
+// New creates an empty Cache
+func New() *Cache {
+	return &Cache{
+		Version: Version,
+		Pages:   make(map[string]*Entry),
+	}
+}
+
+// NewEntry creates an empty Entry for a page generated from source
+func NewEntry(source string) *Entry {
+	return &Entry{
+		Source:     source,
+		Files:      make(map[string]string),
+		Components: make(map[string]string),
+		Variables:  make(map[string]string),
+		Assets:     make(map[string]string),
+	}
+}
+
+// Load reads a cache file. A missing, unreadable or outdated cache yields an
+// empty Cache so the next build regenerates every page.
+func Load(path string) *Cache {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return New()
+	}
+
+	var cache Cache
+	if err := json.Unmarshal(data, &cache); err != nil || cache.Version != Version || cache.Pages == nil {
+		return New()
+	}
+	return &cache
+}
+
+// Save writes the cache file
+func (c *Cache) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "encoding build cache")
+	}
+	return errors.Wrap(os.WriteFile(path, data, 0644), "writing build cache")
+}
+
+// Equal reports whether two entries record the same dependencies with the
+// same hashes, resolve assets to the same output paths and use the same layout
+func (e *Entry) Equal(other *Entry) bool {
+	return e.Source == other.Source &&
+		maps.Equal(e.Files, other.Files) &&
+		maps.Equal(e.Components, other.Components) &&
+		maps.Equal(e.Variables, other.Variables) &&
+		maps.Equal(e.Assets, other.Assets) &&
+		e.Layout == other.Layout
+}
+
+// Hash returns the hex-encoded SHA-256 of data
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// HashFile returns the hex-encoded SHA-256 of a file's contents
+func HashFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return Hash(data), nil
+}
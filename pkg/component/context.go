@@ -0,0 +1,26 @@
+package component
+
+import (
+	"github.com/blamarvt/staticgen/pkg/vars"
+)
+
+// Context is the state shared by every component rendered for one page.
+// Template functions read from it, so all of its fields are optional.
+type Context struct {
+	Page      any           // Metadata of the page being rendered, returned by Page
+	Variables *vars.Store   // Variables read with Var
+	Assets    AssetResolver // Resolves static asset URLs for Asset
+	Data      DataSource    // Structured site data read with Data
+}
+
+// AssetResolver maps the path of a static asset, relative to the static
+// directory, to the URL it is served from
+type AssetResolver interface {
+	AssetURL(name string) (string, error)
+}
+
+// DataSource looks up a value in the site's data files by a dotted path such
+// as "team.members"
+type DataSource interface {
+	Lookup(path string) (any, error)
+}
@@ -1,20 +1,71 @@
 package component
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
+	"sort"
 	"strings"
+	"unicode"
 
+	"github.com/blamarvt/staticgen/pkg/diag"
 	"github.com/blamarvt/staticgen/pkg/internal/xmlutil"
 )
 
 // Definition is the template/function that defines what a component is
 type Definition struct {
 	Name      string
-	Namespace string
+	Namespace string // Namespace the component is registered in
 	Template  string // The Go template text with {{ .Var }} placeholders
 	// Schema info for validation
 	RequiredAttrs []string
 	OptionalAttrs []string
+	Defaults      map[string]string // Values used for optional attributes a page omits
+	RequiredSlots []string
+	OptionalSlots []string
+	SlotFallbacks map[string]string // Markup rendered for optional slots a page leaves empty
+	// Source info for incremental builds and error reporting
+	Path        string        // File the definition was loaded from
+	Checksum    string        // SHA-256 of the definition file contents
+	TemplatePos diag.Position // Where Template begins in the definition file
+}
+
+// AttributeError reports an instance attribute that does not match the
+// schema declared by its component definition
+type AttributeError struct {
+	Component string
+	Attribute string
+	Missing   bool // true if a required attribute was omitted, false if unknown
+}
+
+func (e *AttributeError) Error() string {
+	if e.Missing {
+		return fmt.Sprintf("component %q is missing required attribute %q", e.Component, e.Attribute)
+	}
+	return fmt.Sprintf("component %q has unknown attribute %q", e.Component, e.Attribute)
+}
+
+// SlotError reports slot content that does not match the slots declared by
+// its component definition
+type SlotError struct {
+	Component string
+	Slot      string
+	Missing   bool // true if a required slot was left empty, false if unknown
+}
+
+func (e *SlotError) Error() string {
+	if e.Missing {
+		return fmt.Sprintf("component %q is missing required slot %q", e.Component, e.Slot)
+	}
+	return fmt.Sprintf("component %q has unknown slot %q", e.Component, e.Slot)
+}
+
+// QualifiedName returns the name identifying the definition across namespaces
+func (d *Definition) QualifiedName() string {
+	return QualifiedName(d.Namespace, d.Name)
 }
 
 // LoadDefinition reads a component definition file
@@ -23,27 +74,247 @@ func LoadDefinition(filepath string) (*Definition, error) {
 	if err != nil {
 		return nil, err
 	}
+	return parseDefinition(data, filepath)
+}
+
+// LoadDefinitionFS reads a component definition file from fsys, such as an
+// embed.FS. Errors and the definition's Path refer to name within fsys.
+func LoadDefinitionFS(fsys fs.FS, name string) (*Definition, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	return parseDefinition(data, name)
+}
 
+// parseDefinition parses the contents of a definition file loaded from filepath
+func parseDefinition(data []byte, filepath string) (*Definition, error) {
 	// Parse the XML structure
 	root, err := xmlutil.ParseXML(data)
 	if err != nil {
-		return nil, err
+		return nil, diag.InFile(err, filepath)
 	}
 
 	// Component name is the root element's local name
+	checksum := sha256.Sum256(data)
+	namespace, ok := root.GetAttr("hcmlns")
+	if !ok {
+		namespace = root.GetNamespace()
+	}
 	def := &Definition{
 		Name:          root.XMLName.Local,
-		Namespace:     root.GetNamespace(),
+		Namespace:     namespace,
 		RequiredAttrs: []string{},
 		OptionalAttrs: []string{},
+		Defaults:      map[string]string{},
+		RequiredSlots: []string{},
+		OptionalSlots: []string{},
+		SlotFallbacks: map[string]string{},
+		Path:          filepath,
+		Checksum:      hex.EncodeToString(checksum[:]),
 	}
 
-	// Extract the inner content as the template
+	// Top-level <attribute> and <slot> elements declare the component's schema
+	for _, child := range root.Children {
+		pos := diag.Position{File: filepath, Line: child.Line, Col: child.Col}
+		if child.XMLName.Local == "slot" {
+			if err := def.declareSlot(child); err != nil {
+				return nil, diag.Wrap(pos, err)
+			}
+			continue
+		}
+		if child.XMLName.Local != "attribute" {
+			continue
+		}
+
+		name, ok := child.GetAttr("name")
+		if !ok || name == "" {
+			return nil, diag.Errorf(pos, "component %s: attribute declaration is missing a name", def.Name)
+		}
+		if def.HasAttribute(name) {
+			return nil, diag.Errorf(pos, "component %s: attribute %q is declared more than once", def.Name, name)
+		}
+
+		required, _ := child.GetAttr("required")
+		defaultValue, hasDefault := child.GetAttr("default")
+		switch {
+		case required == "true" && hasDefault:
+			return nil, diag.Errorf(pos, "component %s: required attribute %q cannot have a default", def.Name, name)
+		case required == "true":
+			def.RequiredAttrs = append(def.RequiredAttrs, name)
+		default:
+			def.OptionalAttrs = append(def.OptionalAttrs, name)
+			if hasDefault {
+				def.Defaults[name] = defaultValue
+			}
+		}
+	}
+
+	// Extract the inner content as the template, without the schema declarations
 	// This is the HTML/template content inside the component definition
-	def.Template = string(root.Content)
+	content := root.Content
+	for _, declaration := range []string{"attribute", "slot"} {
+		if content, err = xmlutil.RemoveElements(content, declaration); err != nil {
+			return nil, diag.InFile(err, filepath)
+		}
+	}
+	def.Template = string(content)
 
-	// Clean up the template (remove extra whitespace at start/end)
+	// Clean up the template (remove extra whitespace at start/end), keeping
+	// track of where the remaining text starts so errors point into the file
+	trimmed := strings.TrimLeftFunc(def.Template, unicode.IsSpace)
+	def.TemplatePos = advance(diag.Position{File: filepath, Line: root.ContentLine, Col: root.ContentCol}, def.Template[:len(def.Template)-len(trimmed)])
 	def.Template = strings.TrimSpace(def.Template)
 
 	return def, nil
 }
+
+// advance moves pos past text
+func advance(pos diag.Position, text string) diag.Position {
+	if i := strings.LastIndex(text, "\n"); i >= 0 {
+		pos.Line += strings.Count(text, "\n")
+		pos.Col = len(text) - i
+		return pos
+	}
+	pos.Col += len(text)
+	return pos
+}
+
+// Position maps a line and column within Template to the definition file
+func (d *Definition) Position(line, col int) diag.Position {
+	pos := d.TemplatePos
+	if !pos.IsValid() {
+		return diag.Position{Line: line, Col: col}
+	}
+	if line <= 1 {
+		if col > 0 {
+			pos.Col += col - 1
+		}
+		return pos
+	}
+	pos.Line += line - 1
+	pos.Col = col
+	return pos
+}
+
+// declareSlot records a <slot> declaration. Its content, if any, is the
+// fallback markup rendered when a page leaves the slot empty.
+func (d *Definition) declareSlot(node xmlutil.Node) error {
+	name, ok := node.GetAttr("name")
+	if !ok || name == "" {
+		return fmt.Errorf("component %s: slot declaration is missing a name", d.Name)
+	}
+	if d.HasSlot(name) {
+		return fmt.Errorf("component %s: slot %q is declared more than once", d.Name, name)
+	}
+
+	fallback := strings.TrimSpace(string(node.Content))
+	if required, _ := node.GetAttr("required"); required == "true" {
+		if fallback != "" {
+			return fmt.Errorf("component %s: required slot %q cannot have fallback content", d.Name, name)
+		}
+		d.RequiredSlots = append(d.RequiredSlots, name)
+		return nil
+	}
+
+	d.OptionalSlots = append(d.OptionalSlots, name)
+	if fallback != "" {
+		d.SlotFallbacks[name] = fallback
+	}
+	return nil
+}
+
+// HasSlot reports whether name is declared as a required or optional slot
+func (d *Definition) HasSlot(name string) bool {
+	for _, slot := range d.RequiredSlots {
+		if slot == name {
+			return true
+		}
+	}
+	for _, slot := range d.OptionalSlots {
+		if slot == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateSlots checks the slots a page filled against the declared slots,
+// returning a SlotError for every empty required slot or unknown slot.
+// Components without slot declarations accept any slots.
+func (d *Definition) ValidateSlots(slots map[string][]*Instance) error {
+	if len(d.RequiredSlots) == 0 && len(d.OptionalSlots) == 0 {
+		return nil
+	}
+
+	var errs []error
+	for _, name := range d.RequiredSlots {
+		if len(slots[name]) == 0 {
+			errs = append(errs, &SlotError{Component: d.Name, Slot: name, Missing: true})
+		}
+	}
+
+	// Sort unknown slots so the error is stable across runs
+	var unknown []string
+	for name := range slots {
+		if !d.HasSlot(name) {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		errs = append(errs, &SlotError{Component: d.Name, Slot: name})
+	}
+
+	return errors.Join(errs...)
+}
+
+// HasSchema reports whether the definition declares any attributes. Components
+// without declarations accept any attributes.
+func (d *Definition) HasSchema() bool {
+	return len(d.RequiredAttrs) > 0 || len(d.OptionalAttrs) > 0
+}
+
+// HasAttribute reports whether name is declared as a required or optional attribute
+func (d *Definition) HasAttribute(name string) bool {
+	for _, attr := range d.RequiredAttrs {
+		if attr == name {
+			return true
+		}
+	}
+	for _, attr := range d.OptionalAttrs {
+		if attr == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateAttributes checks instance attributes against the declared schema,
+// returning an AttributeError for every missing or unknown attribute
+func (d *Definition) ValidateAttributes(attrs map[string]string) error {
+	if !d.HasSchema() {
+		return nil
+	}
+
+	var errs []error
+	for _, name := range d.RequiredAttrs {
+		if _, ok := attrs[name]; !ok {
+			errs = append(errs, &AttributeError{Component: d.Name, Attribute: name, Missing: true})
+		}
+	}
+
+	// Sort unknown attributes so the error is stable across runs
+	var unknown []string
+	for name := range attrs {
+		if !d.HasAttribute(name) {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		errs = append(errs, &AttributeError{Component: d.Name, Attribute: name})
+	}
+
+	return errors.Join(errs...)
+}
@@ -2,42 +2,94 @@ package component
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"html/template"
+	"sort"
 	"strings"
-	"text/template"
 
+	"github.com/blamarvt/staticgen/pkg/diag"
 	"github.com/blamarvt/staticgen/pkg/vars"
 )
 
+// ErrNotFound is reported when an instance refers to a component that has not
+// been registered
+var ErrNotFound = errors.New("component definition not found")
+
 // Instance is an instantiation of a definition with specific attribute values
 type Instance struct {
-	DefinitionName string            // e.g., "titleBar"
-	Attributes     map[string]string // e.g., {"icon": "fa-user", "text": "Add User"}
-	Children       []*Instance       // Nested components
-	Slots          map[string]string // Named slots for injecting markup content
-	RawHTML        string            // Raw HTML content (for non-component elements)
+	DefinitionName string                 // e.g., "titleBar"
+	Namespace      string                 // Namespace of the definition; empty means DefaultNamespace
+	Attributes     map[string]string      // e.g., {"icon": "fa-user", "text": "Add User"}
+	Children       []*Instance            // Nested components
+	Slots          map[string][]*Instance // Named slots for injecting rendered content
+	RawHTML        string                 // Raw HTML content (for non-component elements)
+	Pos            diag.Position          // Where the instance appears in its page
 }
 
-// Render generates HTML by applying attributes to the definition's template
+// Render generates HTML by applying attributes to the definition's template.
+// Failures are returned as *diag.Error carrying the position of the failing
+// instance, the position inside its template and the chain of components
+// being rendered.
 func (c *Instance) Render(registry *Registry, variables *vars.Store) (string, error) {
+	return c.RenderContext(registry, &Context{Variables: variables})
+}
+
+// RenderContext is Render with the full page context available to templates
+func (c *Instance) RenderContext(registry *Registry, ctx *Context) (string, error) {
+	if ctx == nil {
+		ctx = &Context{}
+	}
+	return c.render(registry, ctx, nil)
+}
+
+// render renders the instance as a descendant of the components in chain
+func (c *Instance) render(registry *Registry, ctx *Context, chain []string) (string, error) {
 	// If this is a raw HTML instance, just return the HTML directly
 	if c.RawHTML != "" {
 		return c.RawHTML, nil
 	}
 
-	def := registry.Get(c.DefinitionName)
+	name := c.QualifiedName()
+	chain = append(chain[:len(chain):len(chain)], name)
+
+	def, tmpl := registry.lookup(name)
 	if def == nil {
-		return "", fmt.Errorf("component definition not found: %s", c.DefinitionName)
+		return "", &diag.Error{
+			Pos:   c.Pos,
+			Chain: chain,
+			Msg:   fmt.Sprintf("%v: %s", ErrNotFound, name),
+			Err:   ErrNotFound,
+		}
 	}
 
-	// Render all children first
-	var childrenHTML strings.Builder
-	for _, child := range c.Children {
-		childHTML, err := child.Render(registry, variables)
+	// Render all children first. Their errors already describe the full chain.
+	childrenHTML, err := renderAll(c.Children, registry, ctx, chain)
+	if err != nil {
+		return "", err
+	}
+
+	// Render slot contents with the same registry and context as children
+	slotNames := make([]string, 0, len(c.Slots))
+	for name := range c.Slots {
+		slotNames = append(slotNames, name)
+	}
+	sort.Strings(slotNames)
+
+	slots := make(map[string]template.HTML, len(c.Slots))
+	for _, name := range slotNames {
+		slotHTML, err := renderAll(c.Slots[name], registry, ctx, chain)
 		if err != nil {
-			return "", fmt.Errorf("failed to render child component: %w", err)
+			return "", err
+		}
+		slots[name] = template.HTML(slotHTML)
+	}
+
+	// Fall back to the definition's markup for slots the page left empty
+	for name, fallback := range def.SlotFallbacks {
+		if len(c.Slots[name]) == 0 {
+			slots[name] = template.HTML(fallback)
 		}
-		childrenHTML.WriteString(childHTML)
 	}
 
 	// Create template data with attributes and children
@@ -45,40 +97,55 @@ func (c *Instance) Render(registry *Registry, variables *vars.Store) (string, er
 
 	// Add all attributes with capitalized first letter for Go template convention
 	for key, value := range c.Attributes {
-		// Capitalize first letter: "icon" -> "Icon"
-		capitalizedKey := strings.ToUpper(key[:1]) + key[1:]
-		templateData[capitalizedKey] = value
+		templateData[templateKey(key)] = value
 	}
 
-	// Add rendered children
-	templateData["Children"] = childrenHTML.String()
-
-	// Create template with custom functions
-	funcMap := template.FuncMap{
-		"Slot": func(name string) string {
-			if content, exists := c.Slots[name]; exists {
-				return content
-			}
-			return "" // Return empty string if slot not defined
-		},
-		"Var": func(name string) string {
-			if variables != nil {
-				return variables.GetOrDefault(name, "")
-			}
-			return ""
-		},
+	// Fill in declared defaults for attributes the page omitted
+	for key, value := range def.Defaults {
+		if _, ok := c.Attributes[key]; !ok {
+			templateData[templateKey(key)] = value
+		}
 	}
 
-	// Parse and execute the template with custom functions
-	tmpl, err := template.New(c.DefinitionName).Funcs(funcMap).Parse(def.Template)
-	if err != nil {
-		return "", fmt.Errorf("failed to parse template for %s: %w", c.DefinitionName, err)
-	}
+	// Add rendered children, which are trusted markup and must not be escaped
+	templateData["Children"] = template.HTML(childrenHTML)
 
+	// Execute the template compiled at registration, with its functions bound to this instance
 	var output bytes.Buffer
-	if err := tmpl.Execute(&output, templateData); err != nil {
-		return "", fmt.Errorf("failed to execute template for %s: %w", c.DefinitionName, err)
+	scope := &renderScope{slots: slots, ctx: ctx}
+	if err := tmpl.execute(&output, scope, templateData); err != nil {
+		line, col, msg := parseTemplateError(err)
+		return "", &diag.Error{
+			Pos:         c.Pos,
+			TemplatePos: def.Position(line, col),
+			Chain:       chain,
+			Msg:         fmt.Sprintf("failed to execute template for %s: %s", c.DefinitionName, msg),
+			Err:         err,
+		}
 	}
 
 	return output.String(), nil
 }
+
+// renderAll renders instances in order and concatenates the output
+func renderAll(instances []*Instance, registry *Registry, ctx *Context, chain []string) (string, error) {
+	var out strings.Builder
+	for _, inst := range instances {
+		html, err := inst.render(registry, ctx, chain)
+		if err != nil {
+			return "", err
+		}
+		out.WriteString(html)
+	}
+	return out.String(), nil
+}
+
+// QualifiedName returns the name of the instance's definition across namespaces
+func (c *Instance) QualifiedName() string {
+	return QualifiedName(c.Namespace, c.DefinitionName)
+}
+
+// templateKey capitalizes an attribute name for Go template convention: "icon" -> "Icon"
+func templateKey(name string) string {
+	return strings.ToUpper(name[:1]) + name[1:]
+}
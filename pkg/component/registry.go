@@ -1,47 +1,209 @@
 package component
 
 import (
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/blamarvt/staticgen/pkg/diag"
 )
 
-// Registry stores all loaded ComponentDefinitions
+// DefaultNamespace is the namespace of components that are not mounted as a
+// library, and of component tags whose prefix doesn't name a library
+const DefaultNamespace = "staticgen:components"
+
+// Registry stores all loaded ComponentDefinitions along with their compiled
+// templates, keyed by namespace and name. It is safe for concurrent use.
 type Registry struct {
+	mu          sync.RWMutex
 	definitions map[string]*Definition
+	templates   map[string]*compiledTemplate
+	namespaces  map[string]bool
 }
 
 func NewRegistry() *Registry {
 	return &Registry{
 		definitions: make(map[string]*Definition),
+		templates:   make(map[string]*compiledTemplate),
+		namespaces:  make(map[string]bool),
+	}
+}
+
+// QualifiedName identifies a component across namespaces. Components in the
+// default namespace are identified by their name alone, others as
+// "namespace:name".
+func QualifiedName(namespace, name string) string {
+	if namespace == "" || namespace == DefaultNamespace {
+		return name
+	}
+	return namespace + ":" + name
+}
+
+// splitQualifiedName is the inverse of QualifiedName. Component names can't
+// contain colons, so everything before the last one is the namespace.
+func splitQualifiedName(qualified string) (namespace, name string) {
+	i := strings.LastIndex(qualified, ":")
+	if i < 0 {
+		return DefaultNamespace, qualified
 	}
+	return qualified[:i], qualified[i+1:]
+}
+
+// DefaultExtensions are the file extensions LoadAll treats as component
+// definitions when none are given
+var DefaultExtensions = []string{".hcml", ".xml"}
+
+// LoadAll loads all component definitions from a directory. Only files with
+// one of the given extensions are loaded, or DefaultExtensions if none are given.
+// Each definition is registered in the namespace its root element declares,
+// or the default namespace.
+func (r *Registry) LoadAll(dir string, extensions ...string) error {
+	return r.load(os.DirFS(dir), osPath(dir), "", extensions)
 }
 
-// LoadAll loads all component definitions from a directory
-func (r *Registry) LoadAll(dir string) error {
-	return filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+// LoadAllFS is LoadAll for the directory dir within fsys, such as an embed.FS.
+// Use "." to load every definition in fsys.
+func (r *Registry) LoadAllFS(fsys fs.FS, dir string, extensions ...string) error {
+	return r.loadFS(fsys, dir, "", extensions)
+}
+
+// LoadLibrary loads all component definitions from a directory into namespace,
+// whatever namespace the definitions themselves declare. This mounts a shared
+// component library so its components don't clash with the site's own.
+func (r *Registry) LoadLibrary(dir, namespace string, extensions ...string) error {
+	r.mountNamespace(namespace)
+	return r.load(os.DirFS(dir), osPath(dir), namespace, extensions)
+}
+
+// LoadLibraryFS is LoadLibrary for the directory dir within fsys. This lets a
+// Go package ship a component library with //go:embed.
+func (r *Registry) LoadLibraryFS(fsys fs.FS, dir, namespace string, extensions ...string) error {
+	r.mountNamespace(namespace)
+	return r.loadFS(fsys, dir, namespace, extensions)
+}
+
+// mountNamespace records a library's namespace, even before it has components
+func (r *Registry) mountNamespace(namespace string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.namespaces[namespace] = true
+}
+
+// loadFS registers every definition under dir in fsys, naming files by their
+// path within fsys
+func (r *Registry) loadFS(fsys fs.FS, dir, namespace string, extensions []string) error {
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		return err
+	}
+	return r.load(sub, func(name string) string { return path.Join(dir, name) }, namespace, extensions)
+}
+
+// osPath returns a function naming files within dir by their path on disk
+func osPath(dir string) func(string) string {
+	return func(name string) string {
+		return filepath.Join(dir, filepath.FromSlash(name))
+	}
+}
+
+// load registers every definition in fsys, in namespace if it is not empty.
+// Definitions are named with pathOf, so errors point at the original file.
+func (r *Registry) load(fsys fs.FS, pathOf func(string) string, namespace string, extensions []string) error {
+	if len(extensions) == 0 {
+		extensions = DefaultExtensions
+	}
+
+	return fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		var pathErr *fs.PathError
+		if errors.As(err, &pathErr) {
+			pathErr.Path = pathOf(name)
+		}
 		if err != nil || d.IsDir() {
 			return err
 		}
 
-		ext := filepath.Ext(path)
-		if ext != ".xml" && ext != ".hcml" {
+		if !slices.Contains(extensions, path.Ext(name)) {
 			return nil
 		}
 
-		def, err := LoadDefinition(path)
+		data, err := fs.ReadFile(fsys, name)
 		if err != nil {
 			return err
 		}
+		def, err := parseDefinition(data, pathOf(name))
+		if err != nil {
+			return err
+		}
+		if namespace != "" {
+			def.Namespace = namespace
+		}
 
-		r.Register(def)
-		return nil
+		return r.Register(def)
 	})
 }
 
-func (r *Registry) Register(def *Definition) {
-	r.definitions[def.Name] = def
+// Register compiles a definition's template and adds it to the registry under
+// its namespace, or the default namespace if it has none. Templates with
+// syntax errors are rejected here rather than on first use, as a *diag.Error
+// pointing into the definition file. Registering two components with the same
+// name in one namespace is an error.
+func (r *Registry) Register(def *Definition) error {
+	if def.Namespace == "" {
+		def.Namespace = DefaultNamespace
+	}
+
+	tmpl, err := compileTemplate(def.Name, def.Template)
+	if err != nil {
+		line, col, msg := parseTemplateError(err)
+		return &diag.Error{
+			Pos: def.Position(line, col),
+			Msg: fmt.Sprintf("failed to parse template for %s: %s", def.Name, msg),
+			Err: err,
+		}
+	}
+
+	key := def.QualifiedName()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.definitions[key]; ok {
+		return diag.Errorf(diag.Position{File: def.Path}, "component %s in namespace %s is already defined in %s", def.Name, def.Namespace, existing.Path)
+	}
+	r.definitions[key] = def
+	r.templates[key] = tmpl
+	r.namespaces[def.Namespace] = true
+	return nil
+}
+
+// Get returns the definition with the given qualified name, as returned by
+// QualifiedName, or nil if there is none
+func (r *Registry) Get(qualified string) *Definition {
+	return r.Resolve(splitQualifiedName(qualified))
+}
+
+// Resolve returns the definition named name in namespace, or nil if there is none
+func (r *Registry) Resolve(namespace, name string) *Definition {
+	def, _ := r.lookup(QualifiedName(namespace, name))
+	return def
+}
+
+// HasNamespace reports whether any components were registered in namespace,
+// or a library was mounted there
+func (r *Registry) HasNamespace(namespace string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.namespaces[namespace]
 }
 
-func (r *Registry) Get(name string) *Definition {
-	return r.definitions[name]
+// lookup returns a definition together with its compiled template
+func (r *Registry) lookup(qualified string) (*Definition, *compiledTemplate) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.definitions[qualified], r.templates[qualified]
 }
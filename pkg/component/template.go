@@ -0,0 +1,133 @@
+package component
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"regexp"
+	"strconv"
+	"sync"
+
+	"github.com/blamarvt/staticgen/pkg/markdown"
+)
+
+// compiledTemplate is a definition's template, parsed once when the definition
+// is registered. Executions use pooled clones whose template functions are
+// bound to the instance being rendered, so the text is never parsed again.
+//
+// Templates are executed with html/template, so attribute values and variables
+// are escaped for the context they appear in. Rendered markup that staticgen
+// produces itself (children and slots) is passed as template.HTML.
+type compiledTemplate struct {
+	base *template.Template
+	pool sync.Pool
+}
+
+// renderScope holds the per-render state template functions read from
+type renderScope struct {
+	slots map[string]template.HTML // Rendered slot contents by name
+	ctx   *Context
+}
+
+// boundTemplate is a clone of a compiled template whose functions read from
+// scope. A clone is only used by one execution at a time.
+type boundTemplate struct {
+	tmpl  *template.Template
+	scope *renderScope
+}
+
+// compileTemplate parses a definition's template text, reporting syntax errors
+// up front rather than on first use
+func compileTemplate(name, text string) (*compiledTemplate, error) {
+	base, err := template.New(name).Funcs(templateFuncs(&boundTemplate{})).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	ct := &compiledTemplate{base: base}
+	ct.pool.New = func() any {
+		bound := &boundTemplate{}
+		bound.tmpl = template.Must(base.Clone()).Funcs(templateFuncs(bound))
+		return bound
+	}
+	return ct, nil
+}
+
+// execute renders the template with data, binding its functions to scope
+func (ct *compiledTemplate) execute(w io.Writer, scope *renderScope, data any) error {
+	bound := ct.pool.Get().(*boundTemplate)
+	defer ct.pool.Put(bound)
+
+	bound.scope = scope
+	defer func() { bound.scope = nil }()
+
+	return bound.tmpl.Execute(w, data)
+}
+
+// templateErrorPattern matches the location prefix text/template and
+// html/template put on parse and execution errors, e.g.
+// "template: greeting:3:12: executing ..." or "html/template:greeting:3: ..."
+var templateErrorPattern = regexp.MustCompile(`(?s)^(?:html/)?template: ?[^:]*:(\d+)(?::(\d+))?: (.*)$`)
+
+// parseTemplateError splits a template error into the 1-based line and column
+// it occurred at and the remaining message. Line and column are 0 if unknown.
+func parseTemplateError(err error) (line, col int, msg string) {
+	match := templateErrorPattern.FindStringSubmatch(err.Error())
+	if match == nil {
+		return 0, 0, err.Error()
+	}
+	line, _ = strconv.Atoi(match[1])
+	if match[2] != "" {
+		// Execution errors report a 0-based byte offset within the line
+		col, _ = strconv.Atoi(match[2])
+		col++
+	}
+	return line, col, match[3]
+}
+
+// templateFuncs creates the functions available to component templates
+func templateFuncs(bound *boundTemplate) template.FuncMap {
+	return template.FuncMap{
+		"Slot": func(name string) template.HTML {
+			if content, exists := bound.scope.slots[name]; exists {
+				return content
+			}
+			return "" // Return empty string if slot not defined
+		},
+		// Markdown renders Markdown text, such as an attribute, to HTML. Raw
+		// HTML in the text is omitted; use Safe for trusted markup.
+		"Markdown": func(text string) (template.HTML, error) {
+			html, err := markdown.RenderSafe([]byte(text))
+			return template.HTML(html), err
+		},
+		// Safe marks a trusted value as HTML so it is inserted without escaping
+		"Safe": func(value string) template.HTML {
+			return template.HTML(value)
+		},
+		"Var": func(name string) string {
+			if bound.scope.ctx.Variables != nil {
+				return bound.scope.ctx.Variables.GetOrDefault(name, "")
+			}
+			return ""
+		},
+		// Page returns the page being rendered, e.g. {{ Page.Description }}
+		"Page": func() any {
+			return bound.scope.ctx.Page
+		},
+		// Asset returns the URL of a static asset, fingerprinted if enabled
+		"Asset": func(name string) (string, error) {
+			if bound.scope.ctx.Assets == nil {
+				return "", fmt.Errorf("static asset %q requested but no static directory is configured", name)
+			}
+			return bound.scope.ctx.Assets.AssetURL(name)
+		},
+		// Data returns a value from the data directory, e.g.
+		// {{ range (Data "team.members") }}
+		"Data": func(path string) (any, error) {
+			if bound.scope.ctx.Data == nil {
+				return nil, fmt.Errorf("data %q requested but no data is loaded", path)
+			}
+			return bound.scope.ctx.Data.Lookup(path)
+		},
+	}
+}
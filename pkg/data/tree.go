@@ -0,0 +1,209 @@
+package data
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Extensions are the data file formats Load understands
+var Extensions = []string{".yml", ".yaml", ".json", ".csv"}
+
+// Tree is the structured data loaded from a data directory. Every file
+// becomes a key named after its path without the extension, with directories
+// as nested keys: data/team.yml is "team" and data/nav/main.json is
+// "nav.main". YAML and JSON files hold any structure; CSV files are a list of
+// rows, each a map from column header to value.
+//
+// A Tree is never modified after loading and is safe for concurrent use.
+type Tree struct {
+	root  map[string]any
+	files map[string]string // Source file by key path
+}
+
+// Empty returns a tree holding no data
+func Empty() *Tree {
+	return &Tree{root: map[string]any{}, files: map[string]string{}}
+}
+
+// Load reads every data file under dir. A missing directory yields an empty
+// tree.
+func Load(dir string) (*Tree, error) {
+	tree := Empty()
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir && os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		ext := filepath.Ext(path)
+		if d.IsDir() || !isDataFile(ext) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		keys := strings.Split(filepath.ToSlash(strings.TrimSuffix(rel, ext)), "/")
+
+		value, err := loadFile(path, ext)
+		if err != nil {
+			return errors.Wrap(err, "loading data file "+path)
+		}
+		return tree.set(keys, value, path)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+func isDataFile(ext string) bool {
+	for _, e := range Extensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// set stores the contents of file under keys
+func (t *Tree) set(keys []string, value any, file string) error {
+	node := t.root
+	for i, key := range keys[:len(keys)-1] {
+		child, ok := node[key]
+		if !ok {
+			child = map[string]any{}
+			node[key] = child
+		}
+		dir, ok := child.(map[string]any)
+		if !ok || t.files[strings.Join(keys[:i+1], ".")] != "" {
+			return fmt.Errorf("data file %s conflicts with %s", file, t.files[strings.Join(keys[:i+1], ".")])
+		}
+		node = dir
+	}
+
+	key := keys[len(keys)-1]
+	if _, ok := node[key]; ok {
+		return fmt.Errorf("data file %s conflicts with data already loaded for %q", file, strings.Join(keys, "."))
+	}
+	node[key] = value
+	t.files[strings.Join(keys, ".")] = file
+	return nil
+}
+
+// loadFile decodes a data file by its extension
+func loadFile(path, ext string) (any, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var value any
+	switch ext {
+	case ".json":
+		err = json.Unmarshal(raw, &value)
+	case ".csv":
+		value, err = decodeCSV(raw)
+	default:
+		err = yaml.Unmarshal(raw, &value)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return normalize(value), nil
+}
+
+// decodeCSV turns a CSV file into a list of rows keyed by the header row
+func decodeCSV(raw []byte) (any, error) {
+	records, err := csv.NewReader(strings.NewReader(string(raw))).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	rows := []any{}
+	if len(records) == 0 {
+		return rows, nil
+	}
+	header := records[0]
+	for _, record := range records[1:] {
+		row := make(map[string]any, len(header))
+		for i, column := range header {
+			row[column] = record[i]
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// normalize converts YAML mappings with non-string keys into string-keyed
+// maps, so every mapping in the tree has the same type
+func normalize(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			v[key] = normalize(child)
+		}
+		return v
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for key, child := range v {
+			m[fmt.Sprint(key)] = normalize(child)
+		}
+		return m
+	case []any:
+		for i, child := range v {
+			v[i] = normalize(child)
+		}
+		return v
+	}
+	return value
+}
+
+// Lookup returns the value at a dotted path such as "team.members" or
+// "team.members.0.name". List elements are addressed by index.
+func (t *Tree) Lookup(path string) (any, error) {
+	var value any = t.root
+	for _, key := range strings.Split(path, ".") {
+		switch node := value.(type) {
+		case map[string]any:
+			child, ok := node[key]
+			if !ok {
+				return nil, fmt.Errorf("no data at %q", path)
+			}
+			value = child
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, fmt.Errorf("no data at %q: %q is not an index of the list", path, key)
+			}
+			value = node[i]
+		default:
+			return nil, fmt.Errorf("no data at %q", path)
+		}
+	}
+	return value, nil
+}
+
+// Files returns the data files a lookup of path reads from, sorted: the file
+// holding the value, or every file below it when path names a directory
+func (t *Tree) Files(path string) []string {
+	var files []string
+	for key, file := range t.files {
+		if key == path || strings.HasPrefix(path, key+".") || strings.HasPrefix(key, path+".") {
+			files = append(files, file)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
@@ -0,0 +1,251 @@
+package devserver
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ReloadPath is the server-sent events endpoint browsers listen on for reloads
+const ReloadPath = "/_staticgen/reload"
+
+// reloadScript is injected into every HTML page so open browsers reload after
+// a successful rebuild
+const reloadScript = `<script>new EventSource("` + ReloadPath + `").addEventListener("reload", function () { location.reload(); });</script>`
+
+// BuildFunc generates the site into the given output directory
+type BuildFunc func(outputDir string) error
+
+// Server builds a site into a temporary directory, serves it over HTTP and
+// rebuilds it whenever a watched file changes
+type Server struct {
+	build    BuildFunc
+	interval time.Duration
+
+	mu      sync.RWMutex
+	watch   []string
+	root    string
+	clients map[chan struct{}]struct{}
+}
+
+// New creates a Server that polls the watched files and directories for changes
+func New(build BuildFunc, watch ...string) *Server {
+	return &Server{
+		build:    build,
+		watch:    watch,
+		interval: 500 * time.Millisecond,
+		clients:  make(map[chan struct{}]struct{}),
+	}
+}
+
+// SetWatch replaces the watched files and directories, e.g. after a change to
+// the configuration that names them. It may be called from the BuildFunc.
+func (s *Server) SetWatch(watch ...string) {
+	s.mu.Lock()
+	s.watch = watch
+	s.mu.Unlock()
+}
+
+// watched returns the watched files and directories
+func (s *Server) watched() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.watch
+}
+
+// Rebuild builds the site into a fresh temporary directory and, on success,
+// swaps it in and tells connected browsers to reload. The previous build keeps
+// being served if the new one fails.
+func (s *Server) Rebuild() error {
+	dir, err := os.MkdirTemp("", "staticgen-serve-")
+	if err != nil {
+		return errors.Wrap(err, "creating build directory")
+	}
+
+	if err := s.build(dir); err != nil {
+		os.RemoveAll(dir)
+		return err
+	}
+
+	s.mu.Lock()
+	previous := s.root
+	s.root = dir
+	for client := range s.clients {
+		select {
+		case client <- struct{}{}:
+		default:
+			// A reload is already pending for this client
+		}
+	}
+	s.mu.Unlock()
+
+	if previous != "" {
+		os.RemoveAll(previous)
+	}
+	return nil
+}
+
+// Watch polls the watched paths until ctx is cancelled, rebuilding after every change
+func (s *Server) Watch(ctx context.Context) {
+	last := s.snapshot()
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current := s.snapshot()
+		if current == last {
+			continue
+		}
+		last = current
+
+		log.Printf("Change detected, rebuilding...")
+		watch := s.watched()
+		err := s.Rebuild()
+		if !slices.Equal(watch, s.watched()) {
+			// The build watches other paths now; compare against those
+			last = s.snapshot()
+		}
+		if err != nil {
+			log.Printf("Build failed: %v", err)
+			continue
+		}
+		log.Printf("Rebuild complete")
+	}
+}
+
+// snapshot summarizes the name, size and modification time of every watched
+// file so changes, additions and deletions can be detected
+func (s *Server) snapshot() string {
+	var b strings.Builder
+	for _, root := range s.watched() {
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			fmt.Fprintf(&b, "%s\x00%d\x00%d\n", path, info.Size(), info.ModTime().UnixNano())
+			return nil
+		})
+	}
+	return b.String()
+}
+
+// Close removes the current build directory
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.root == "" {
+		return nil
+	}
+	err := os.RemoveAll(s.root)
+	s.root = ""
+	return err
+}
+
+// ServeHTTP serves the most recent build, injecting the reload script into HTML pages
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == ReloadPath {
+		s.serveEvents(w, r)
+		return
+	}
+
+	s.mu.RLock()
+	root := s.root
+	s.mu.RUnlock()
+
+	if root == "" {
+		http.Error(w, "site has not been built yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	name := path.Clean("/" + r.URL.Path)
+	if strings.HasSuffix(r.URL.Path, "/") {
+		name = path.Join(name, "index.html")
+	}
+
+	if path.Ext(name) != ".html" {
+		http.FileServer(http.Dir(root)).ServeHTTP(w, r)
+		return
+	}
+
+	data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(name)))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(injectReloadScript(data))
+}
+
+// serveEvents streams a "reload" event to the browser after every rebuild
+func (s *Server) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	client := make(chan struct{}, 1)
+	s.mu.Lock()
+	s.clients[client] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.clients, client)
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-client:
+			fmt.Fprint(w, "event: reload\ndata: {}\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// injectReloadScript inserts the reload script before the closing body tag,
+// or appends it if the page has none
+func injectReloadScript(page []byte) []byte {
+	idx := bytes.LastIndex(bytes.ToLower(page), []byte("</body>"))
+	if idx < 0 {
+		return append(page, reloadScript...)
+	}
+
+	out := make([]byte, 0, len(page)+len(reloadScript))
+	out = append(out, page[:idx]...)
+	out = append(out, reloadScript...)
+	return append(out, page[idx:]...)
+}
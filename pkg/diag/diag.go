@@ -0,0 +1,96 @@
+package diag
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Position is a location in a source file. Line and Col are 1-based; zero
+// means unknown.
+type Position struct {
+	File string
+	Line int
+	Col  int
+}
+
+// IsValid reports whether the position refers to a file or a line
+func (p Position) IsValid() bool {
+	return p.File != "" || p.Line > 0
+}
+
+// String formats the position as file:line:col, leaving out unknown parts
+func (p Position) String() string {
+	var parts []string
+	if p.File != "" {
+		parts = append(parts, p.File)
+	}
+	if p.Line > 0 {
+		parts = append(parts, fmt.Sprint(p.Line))
+		if p.Col > 0 {
+			parts = append(parts, fmt.Sprint(p.Col))
+		}
+	}
+	return strings.Join(parts, ":")
+}
+
+// Error is a parse or render failure tied to a location in a page or
+// component file
+type Error struct {
+	Pos         Position // Page element or file being parsed
+	TemplatePos Position // Location inside a component template, if any
+	Chain       []string // Components being rendered, outermost first
+	Msg         string
+	Err         error // Underlying error, if any
+}
+
+// Error formats the failure like a compiler diagnostic:
+//
+//	pages/index.hcml:7:5: message
+//		templates/greeting.hcml:4:20: in component template
+//		while rendering skeleton > container > greeting
+func (e *Error) Error() string {
+	var b strings.Builder
+	if e.Pos.IsValid() {
+		b.WriteString(e.Pos.String())
+		b.WriteString(": ")
+	}
+	b.WriteString(e.Msg)
+	if e.TemplatePos.IsValid() {
+		fmt.Fprintf(&b, "\n\t%s: in component template", e.TemplatePos)
+	}
+	if len(e.Chain) > 0 {
+		fmt.Fprintf(&b, "\n\twhile rendering %s", strings.Join(e.Chain, " > "))
+	}
+	return b.String()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Errorf creates an Error at pos with a formatted message
+func Errorf(pos Position, format string, args ...any) *Error {
+	return &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// Wrap creates an Error at pos for err, keeping err's message
+func Wrap(pos Position, err error) *Error {
+	return &Error{Pos: pos, Msg: err.Error(), Err: err}
+}
+
+// As returns the first Error in err's chain, if any
+func As(err error) (*Error, bool) {
+	var diagErr *Error
+	ok := errors.As(err, &diagErr)
+	return diagErr, ok
+}
+
+// InFile fills in the file of a positioned error that was produced without
+// knowing which file it came from. Other errors are returned unchanged.
+func InFile(err error, file string) error {
+	if diagErr, ok := As(err); ok && diagErr.Pos.File == "" {
+		diagErr.Pos.File = file
+	}
+	return err
+}
@@ -1,25 +1,129 @@
 package xmlutil
 
 import (
+	"bytes"
 	"encoding/xml"
+	"errors"
 	"fmt"
+	"io"
+
+	"github.com/blamarvt/staticgen/pkg/diag"
 )
 
 // Node represents a generic XML element for parsing
 type Node struct {
 	XMLName  xml.Name
-	Attrs    []xml.Attr `xml:",any,attr"`
-	Content  []byte     `xml:",innerxml"`
-	Children []Node     `xml:",any"`
+	Attrs    []xml.Attr
+	Content  []byte // Raw inner XML
+	Children []Node // Child elements, in document order
+
+	Line, Col               int // Position of the start tag
+	ContentLine, ContentCol int // Position where Content begins
+
+	start, end   int64 // Byte offsets of the element within the document
+	contentStart int64 // Byte offset where Content begins
 }
 
-// ParseXML parses XML data into a Node structure
+// ParseXML parses XML data into a Node structure. Syntax errors are returned
+// as *diag.Error carrying the line they occurred on.
 func ParseXML(data []byte) (*Node, error) {
-	var root Node
-	if err := xml.Unmarshal(data, &root); err != nil {
-		return nil, fmt.Errorf("failed to parse XML: %w", err)
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	pos := &positions{data: data, line: 1, col: 1}
+
+	var stack []*Node
+	var starts []int64
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, syntaxError(err, decoder, pos, offset)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			line, col := pos.at(offset)
+			contentOffset := decoder.InputOffset()
+			contentLine, contentCol := pos.at(contentOffset)
+			stack = append(stack, &Node{
+				XMLName:      t.Name,
+				Attrs:        t.Attr,
+				Line:         line,
+				Col:          col,
+				ContentLine:  contentLine,
+				ContentCol:   contentCol,
+				start:        offset,
+				contentStart: contentOffset,
+			})
+			starts = append(starts, contentOffset)
+
+		case xml.EndElement:
+			node := stack[len(stack)-1]
+			node.Content = data[starts[len(starts)-1]:offset]
+			node.end = decoder.InputOffset()
+			stack, starts = stack[:len(stack)-1], starts[:len(starts)-1]
+
+			if len(stack) == 0 {
+				// The root element is complete; anything after it is ignored
+				return node, nil
+			}
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, *node)
+		}
+	}
+
+	if len(stack) > 0 {
+		line, col := pos.at(int64(len(data)))
+		return nil, &diag.Error{Pos: diag.Position{Line: line, Col: col}, Msg: fmt.Sprintf("failed to parse XML: element <%s> is never closed", stack[len(stack)-1].XMLName.Local)}
+	}
+	return nil, &diag.Error{Pos: diag.Position{Line: 1}, Msg: "failed to parse XML: no root element"}
+}
+
+// syntaxError converts a decoder error into a positioned error. Syntax errors
+// are reported where the decoder stopped reading.
+func syntaxError(err error, decoder *xml.Decoder, pos *positions, offset int64) error {
+	var syntaxErr *xml.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		line, col := decoder.InputPos()
+		return &diag.Error{Pos: diag.Position{Line: line, Col: col}, Msg: "failed to parse XML: " + syntaxErr.Msg, Err: err}
+	}
+	line, col := pos.at(offset)
+	return &diag.Error{Pos: diag.Position{Line: line, Col: col}, Msg: "failed to parse XML: " + err.Error(), Err: err}
+}
+
+// positions converts byte offsets into line and column numbers. Offsets must
+// be requested in increasing order.
+type positions struct {
+	data      []byte
+	offset    int64
+	line, col int
+}
+
+func (p *positions) at(offset int64) (int, int) {
+	for ; p.offset < offset && p.offset < int64(len(p.data)); p.offset++ {
+		if p.data[p.offset] == '\n' {
+			p.line++
+			p.col = 1
+		} else {
+			p.col++
+		}
 	}
-	return &root, nil
+	return p.line, p.col
+}
+
+// Text returns the raw XML of the content around the node's child elements:
+// the text before the first child, between each pair of children and after
+// the last one. It always has one more entry than Children.
+func (n *Node) Text() [][]byte {
+	text := make([][]byte, 0, len(n.Children)+1)
+	from := int64(0)
+	for _, child := range n.Children {
+		text = append(text, n.Content[from:child.start-n.contentStart])
+		from = child.end - n.contentStart
+	}
+	return append(text, n.Content[from:])
 }
 
 // GetAttr retrieves an attribute value by name
@@ -36,3 +140,47 @@ func (n *Node) GetAttr(name string) (string, bool) {
 func (n *Node) GetNamespace() string {
 	return n.XMLName.Space
 }
+
+// RemoveElements returns content with every top-level element named local
+// removed, along with its children. Removed elements are replaced by the
+// newlines they contained so line numbers in the remaining content still
+// match the original.
+func RemoveElements(content []byte, local string) ([]byte, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+
+	var out bytes.Buffer
+	var last, start int64
+	depth := 0
+	removing := false
+
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse XML: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if depth == 0 && t.Name.Local == local {
+				removing = true
+				start = offset
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+			if depth == 0 && removing {
+				out.Write(content[last:start])
+				last = decoder.InputOffset()
+				out.Write(bytes.Repeat([]byte("\n"), bytes.Count(content[start:last], []byte("\n"))))
+				removing = false
+			}
+		}
+	}
+	out.Write(content[last:])
+
+	return out.Bytes(), nil
+}
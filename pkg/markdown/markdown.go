@@ -0,0 +1,70 @@
+package markdown
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer/html"
+)
+
+// converter renders CommonMark with the GitHub extensions: tables,
+// strikethrough, autolinks and task lists. Markdown pages and the files they
+// include are the site's own sources, so raw HTML in them is kept like the
+// markup of a page.
+var converter = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	goldmark.WithRendererOptions(html.WithUnsafe()),
+)
+
+// safeConverter renders like converter but omits raw HTML and drops
+// dangerous link URLs, for text that may come from data or attributes
+var safeConverter = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+// Render converts Markdown from the site's sources to HTML, keeping raw HTML
+func Render(source []byte) (string, error) {
+	return convert(converter, source)
+}
+
+// RenderSafe converts Markdown to HTML without passing raw HTML through, so
+// it can render values that are not trusted as markup
+func RenderSafe(source []byte) (string, error) {
+	return convert(safeConverter, source)
+}
+
+func convert(md goldmark.Markdown, source []byte) (string, error) {
+	var out bytes.Buffer
+	if err := md.Convert(source, &out); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// frontMatterDelimiter opens and closes the YAML front matter of a Markdown file
+const frontMatterDelimiter = "---"
+
+// SplitFrontMatter separates the YAML front matter between --- lines at the
+// start of a Markdown file from the Markdown after it. Files without front
+// matter return it empty.
+func SplitFrontMatter(source []byte) (frontMatter, body []byte) {
+	first, rest, found := bytes.Cut(source, []byte("\n"))
+	if !found || !isDelimiter(first) {
+		return nil, source
+	}
+
+	for start := 0; start < len(rest); {
+		line, _, _ := bytes.Cut(rest[start:], []byte("\n"))
+		next := min(start+len(line)+1, len(rest))
+		if isDelimiter(line) {
+			return rest[:start], rest[next:]
+		}
+		start = next
+	}
+	// Without a closing delimiter the --- is Markdown, a thematic break
+	return nil, source
+}
+
+// isDelimiter reports whether line is a front matter delimiter
+func isDelimiter(line []byte) bool {
+	return string(bytes.TrimRight(line, " \t\r")) == frontMatterDelimiter
+}
@@ -0,0 +1,93 @@
+package page
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/blamarvt/staticgen/pkg/diag"
+)
+
+// placeholderPattern matches the {field} placeholders of collection pages,
+// e.g. {slug} or {author.name}
+var placeholderPattern = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// checkCollection makes sure a collection page can give each item its own
+// output path
+func (p *Page) checkCollection() error {
+	if p.Collection == "" {
+		return nil
+	}
+	if !placeholderPattern.MatchString(p.Path) {
+		return diag.Errorf(p.pos, "collection page needs a path with placeholders, like path=\"/products/{slug}.html\"")
+	}
+	return nil
+}
+
+// ForItem returns the page generated for one item of its collection. The
+// item is available to templates as Page.Item, and {field} placeholders in
+// the path, title and description are replaced with the item's fields. Fields
+// placed in the path may not contain slashes or "..", so data files can't
+// move a page outside its directory.
+func (p *Page) ForItem(item any) (*Page, error) {
+	expanded := *p
+	expanded.Item = item
+	for _, field := range []struct {
+		value    *string
+		pathSafe bool
+	}{{&expanded.Path, true}, {&expanded.Title, false}, {&expanded.Description, false}} {
+		value, err := expandPlaceholders(*field.value, item, field.pathSafe)
+		if err != nil {
+			return nil, diag.Wrap(p.pos, err)
+		}
+		*field.value = value
+	}
+	return &expanded, nil
+}
+
+// expandPlaceholders replaces every {field} in s with the item's value for it.
+// With pathSafe, values that could change the directory of a path are errors.
+func expandPlaceholders(s string, item any, pathSafe bool) (string, error) {
+	var failed error
+	expanded := placeholderPattern.ReplaceAllStringFunc(s, func(placeholder string) string {
+		name := placeholder[1 : len(placeholder)-1]
+		value, ok := itemField(item, name)
+		switch {
+		case failed != nil:
+		case !ok:
+			failed = fmt.Errorf("collection item has no field %q for placeholder %s", name, placeholder)
+		case pathSafe && (strings.ContainsAny(value, `/\`) || strings.Contains(value, "..")):
+			failed = fmt.Errorf("collection item field %q is %q, which can't be used in a path", name, value)
+		}
+		return value
+	})
+	return expanded, failed
+}
+
+// itemField returns the field of an item at a dotted path, formatted as text
+func itemField(item any, name string) (string, bool) {
+	value := item
+	for _, key := range strings.Split(name, ".") {
+		switch node := value.(type) {
+		case map[string]any:
+			child, ok := node[key]
+			if !ok {
+				return "", false
+			}
+			value = child
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return "", false
+			}
+			value = node[i]
+		default:
+			return "", false
+		}
+	}
+	if value == nil {
+		return "", false
+	}
+	return fmt.Sprint(value), true
+}
@@ -12,11 +12,32 @@ import (
 
 // Generate creates the final HTML from a page
 func Generate(p *Page, registry *component.Registry, variables *vars.Store) (string, error) {
+	return GenerateContext(p, registry, &component.Context{Variables: variables})
+}
+
+// GenerateContext is Generate with the full page context available to
+// component templates. The page is made available to templates unless ctx
+// already carries one.
+func GenerateContext(p *Page, registry *component.Registry, ctx *component.Context) (string, error) {
+	if ctx == nil {
+		ctx = &component.Context{}
+	}
+	if ctx.Page == nil {
+		withPage := *ctx
+		withPage.Page = p
+		ctx = &withPage
+	}
+
+	body, err := p.body(registry)
+	if err != nil {
+		return "", err
+	}
+
 	var hb strings.Builder
 
 	// Render each component
-	for _, comp := range p.Components {
-		rendered, err := comp.Render(registry, variables)
+	for _, comp := range body {
+		rendered, err := comp.RenderContext(registry, ctx)
 		if err != nil {
 			return "", err
 		}
@@ -0,0 +1,141 @@
+package page
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/blamarvt/staticgen/pkg/component"
+	"github.com/blamarvt/staticgen/pkg/diag"
+	"github.com/blamarvt/staticgen/pkg/internal/xmlutil"
+	"github.com/blamarvt/staticgen/pkg/markdown"
+)
+
+// MarkdownComponent is the built-in component that renders Markdown in place,
+// from its text or from the file named by its src attribute. A registered
+// component with the same name takes precedence.
+const MarkdownComponent = "markdown"
+
+// readRelative reads a file a page refers to by a path relative to the page
+// file, returning the path it was read from
+type readRelative func(name string) (string, []byte, error)
+
+// osReader reads files relative to a page file on disk
+func osReader(file string) readRelative {
+	return func(name string) (string, []byte, error) {
+		path := filepath.Join(filepath.Dir(file), filepath.FromSlash(name))
+		data, err := os.ReadFile(path)
+		return path, data, err
+	}
+}
+
+// fsReader reads files relative to a page file within fsys
+func fsReader(fsys fs.FS, file string) readRelative {
+	return func(name string) (string, []byte, error) {
+		name = path.Join(path.Dir(file), name)
+		data, err := fs.ReadFile(fsys, name)
+		return name, data, err
+	}
+}
+
+// isMarkdown reports whether an element is the built-in Markdown component
+func (pp *pageParser) isMarkdown(node xmlutil.Node) bool {
+	namespace, _ := pp.namespace(node.XMLName.Space)
+	return node.XMLName.Local == MarkdownComponent &&
+		namespace == component.DefaultNamespace &&
+		pp.registry.Resolve(component.DefaultNamespace, MarkdownComponent) == nil
+}
+
+// parseMarkdown renders a Markdown element to raw HTML. Its text is wrapped
+// in CDATA to use < and & freely, and is dedented so it can be indented along
+// with the page.
+func (pp *pageParser) parseMarkdown(node xmlutil.Node) (*component.Instance, error) {
+	var src string
+	for _, attr := range node.Attrs {
+		switch {
+		case isNamespaceDeclaration(attr):
+		case attr.Name.Local == "src":
+			src = attr.Value
+		default:
+			return nil, diag.Errorf(pp.pos(node), "markdown has no attribute %q; only src is allowed", attr.Name.Local)
+		}
+	}
+
+	text, err := elementText(node)
+	if err != nil {
+		return nil, diag.Wrap(pp.pos(node), err)
+	}
+
+	var source []byte
+	switch {
+	case src != "" && strings.TrimSpace(text) != "":
+		return nil, diag.Errorf(pp.pos(node), "markdown takes either a src attribute or text, not both")
+	case src != "":
+		file, data, err := pp.read(src)
+		if err != nil {
+			return nil, diag.Errorf(pp.pos(node), "reading markdown file: %v", err)
+		}
+		pp.includes = append(pp.includes, file)
+		source = data
+	default:
+		source = []byte(dedent(text))
+	}
+
+	html, err := markdown.Render(source)
+	if err != nil {
+		return nil, diag.Wrap(pp.pos(node), err)
+	}
+	return &component.Instance{RawHTML: html}, nil
+}
+
+// elementText returns the text inside an element, with entities decoded and
+// CDATA sections unwrapped. Markup inside the element is an error, since it
+// would need escaping to be read as Markdown.
+func elementText(node xmlutil.Node) (string, error) {
+	var text strings.Builder
+	decoder := xml.NewDecoder(strings.NewReader(string(node.Content)))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return text.String(), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		switch t := token.(type) {
+		case xml.CharData:
+			text.Write(t)
+		case xml.StartElement:
+			return "", fmt.Errorf("markdown can't contain the element <%s>; wrap the text in <![CDATA[ ... ]]>", t.Name.Local)
+		}
+	}
+}
+
+// dedent removes the indentation shared by every non-blank line of text, and
+// the blank lines around it
+func dedent(text string) string {
+	lines := strings.Split(strings.Trim(text, "\r\n"), "\n")
+	indent := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		width := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent < 0 || width < indent {
+			indent = width
+		}
+	}
+	for i, line := range lines {
+		if len(line) >= indent && indent > 0 {
+			lines[i] = line[indent:]
+		} else {
+			lines[i] = strings.TrimLeft(line, " \t")
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,84 @@
+package page
+
+import (
+	"strings"
+
+	"github.com/blamarvt/staticgen/pkg/component"
+	"github.com/blamarvt/staticgen/pkg/diag"
+)
+
+// SetDefaultLayout wraps the page in layout, given as a qualified component
+// name, unless the page chose a layout itself with the layout attribute
+func (p *Page) SetDefaultLayout(layout string) {
+	if !p.layoutSet {
+		p.Layout = layout
+	}
+}
+
+// metadataAttributes returns the page metadata passed to its layout as
+// attributes, leaving out values the page doesn't set
+func (p *Page) metadataAttributes() map[string]string {
+	attrs := map[string]string{}
+	for name, value := range p.Params {
+		attrs[name] = value
+	}
+	for name, value := range map[string]string{
+		"title":       p.Title,
+		"path":        p.Path,
+		"description": p.Description,
+		"tags":        strings.Join(p.Tags, ", "),
+	} {
+		if value != "" {
+			attrs[name] = value
+		}
+	}
+	return attrs
+}
+
+// body returns the instances rendered for the page: its components, wrapped
+// in its layout if it has one. The layout receives the page metadata it
+// declares as attributes, the page's components as children and the content
+// of top-level slot elements as slots.
+func (p *Page) body(registry *component.Registry) ([]*component.Instance, error) {
+	if p.Layout == "" {
+		return p.Components, nil
+	}
+
+	def := registry.Get(p.Layout)
+	if def == nil {
+		return nil, &diag.Error{
+			Pos: p.pos,
+			Msg: "layout " + component.ErrNotFound.Error() + ": " + p.Layout,
+			Err: component.ErrNotFound,
+		}
+	}
+
+	layout := &component.Instance{
+		DefinitionName: def.Name,
+		Namespace:      def.Namespace,
+		Attributes:     make(map[string]string),
+		Slots:          make(map[string][]*component.Instance),
+		Pos:            p.pos,
+	}
+	for name, value := range p.metadataAttributes() {
+		if !def.HasSchema() || def.HasAttribute(name) {
+			layout.Attributes[name] = value
+		}
+	}
+	for _, inst := range p.Components {
+		if inst.DefinitionName == slotMarker {
+			name := inst.Attributes["name"]
+			layout.Slots[name] = append(layout.Slots[name], inst.Children...)
+			continue
+		}
+		layout.Children = append(layout.Children, inst)
+	}
+
+	if err := def.ValidateAttributes(layout.Attributes); err != nil {
+		return nil, diag.Wrap(p.pos, err)
+	}
+	if err := def.ValidateSlots(layout.Slots); err != nil {
+		return nil, diag.Wrap(p.pos, err)
+	}
+	return []*component.Instance{layout}, nil
+}
@@ -0,0 +1,93 @@
+package page
+
+import (
+	"encoding/xml"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/blamarvt/staticgen/pkg/component"
+	"github.com/blamarvt/staticgen/pkg/diag"
+	"github.com/blamarvt/staticgen/pkg/markdown"
+)
+
+// MarkdownExtension is the file extension of Markdown pages
+const MarkdownExtension = ".md"
+
+// loadMarkdownPage parses a Markdown page. Its YAML front matter holds the
+// metadata a <page> element has as attributes, and the Markdown after it is
+// rendered as the page's only content, so a layout receives it as .Children.
+func loadMarkdownPage(data []byte, filepath string, registry *component.Registry, _ readRelative) (*Page, error) {
+	page := &Page{
+		Params:     map[string]string{},
+		Components: []*component.Instance{},
+		pos:        diag.Position{File: filepath, Line: 1, Col: 1},
+	}
+
+	frontMatter, body := markdown.SplitFrontMatter(data)
+	fields := map[string]any{}
+	if err := yaml.Unmarshal(frontMatter, &fields); err != nil {
+		return nil, diag.Wrap(page.pos, fmt.Errorf("invalid front matter: %w", err))
+	}
+	attrs := frontMatterAttrs(fields)
+	if err := page.setMetadata(attrs); err != nil {
+		return nil, diag.Wrap(page.pos, err)
+	}
+	if layout, ok := fields["layout"]; ok {
+		parser := &pageParser{file: filepath, registry: registry}
+		qualified, err := parser.qualify(frontMatterValue(layout), page.pos)
+		if err != nil {
+			return nil, err
+		}
+		page.Layout = qualified
+		page.layoutSet = true
+	}
+	if err := page.checkCollection(); err != nil {
+		return nil, err
+	}
+	if err := page.checkPagination(); err != nil {
+		return nil, err
+	}
+
+	html, err := markdown.Render(body)
+	if err != nil {
+		return nil, diag.Wrap(page.pos, err)
+	}
+	page.Components = append(page.Components, &component.Instance{RawHTML: html})
+	return page, nil
+}
+
+// frontMatterAttrs turns front matter fields into the attributes a <page>
+// element would have, in name order
+func frontMatterAttrs(fields map[string]any) []xml.Attr {
+	attrs := make([]xml.Attr, 0, len(fields))
+	for _, name := range slices.Sorted(maps.Keys(fields)) {
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: name}, Value: frontMatterValue(fields[name])})
+	}
+	return attrs
+}
+
+// frontMatterValue formats a front matter value as an attribute value. Lists,
+// such as tags, are joined with commas.
+func frontMatterValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []any:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = frontMatterValue(item)
+		}
+		return strings.Join(items, ", ")
+	case time.Time:
+		if v.Equal(v.Truncate(24 * time.Hour)) {
+			return v.Format("2006-01-02")
+		}
+		return v.Format(time.RFC3339)
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,105 @@
+package page
+
+import (
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateLayouts are the accepted formats of date attributes, the W3C datetime
+// profiles sitemaps and feeds allow
+var dateLayouts = []string{"2006-01-02", "2006-01-02T15:04Z07:00", time.RFC3339}
+
+// setMetadata fills in the page's metadata from the attributes of its <page>
+// element. Attributes staticgen doesn't know about are kept in Params.
+func (p *Page) setMetadata(attrs []xml.Attr) error {
+	for _, attr := range attrs {
+		if isNamespaceDeclaration(attr) {
+			continue
+		}
+
+		value := attr.Value
+		switch attr.Name.Local {
+		case "title":
+			p.Title = value
+		case "path":
+			p.Path = value
+		case "description":
+			p.Description = value
+		case "tags":
+			p.Tags = splitTags(value)
+		case "date":
+			date, err := parseDate(value)
+			if err != nil {
+				return fmt.Errorf("invalid date %q: %w", value, err)
+			}
+			p.Date = date
+		case "draft":
+			draft, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid draft %q: expected true or false", value)
+			}
+			p.Draft = draft
+		case "lastmod":
+			if _, err := parseDate(value); err != nil {
+				return fmt.Errorf("invalid lastmod %q: %w", value, err)
+			}
+			p.LastMod = value
+		case "collection":
+			p.Collection = value
+		case "paginate":
+			p.Paginate = value
+		case "per_page":
+			perPage, err := strconv.Atoi(value)
+			if err != nil || perPage < 1 {
+				return fmt.Errorf("invalid per_page %q: expected a positive number", value)
+			}
+			p.PerPage = perPage
+		case "layout":
+			// Resolved by the page parser, which knows the namespace prefixes
+		case "sitemap":
+			include, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid sitemap %q: expected true or false", value)
+			}
+			p.NoSitemap = !include
+		default:
+			p.Params[attr.Name.Local] = value
+		}
+	}
+	return nil
+}
+
+// Param returns a custom metadata attribute, or "" if the page doesn't set it
+func (p *Page) Param(name string) string {
+	return p.Params[name]
+}
+
+// HasTag reports whether the page is tagged with tag
+func (p *Page) HasTag(tag string) bool {
+	return slices.Contains(p.Tags, tag)
+}
+
+// parseDate parses a date attribute in one of dateLayouts
+func parseDate(value string) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("expected a date like 2006-01-02 or an RFC 3339 time")
+}
+
+// splitTags splits a comma-separated tags attribute, dropping empty tags
+func splitTags(value string) []string {
+	var tags []string
+	for tag := range strings.SplitSeq(value, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
@@ -1,76 +1,242 @@
 package page
 
 import (
-	"fmt"
+	"encoding/xml"
+	"io/fs"
 	"os"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 
 	"github.com/blamarvt/staticgen/pkg/component"
+	"github.com/blamarvt/staticgen/pkg/diag"
 	"github.com/blamarvt/staticgen/pkg/internal/xmlutil"
 )
 
-// Page represents a complete page with metadata and components
+// Page represents a complete page with metadata and components. Metadata
+// comes from the attributes of the <page> element and is available to
+// component templates through the Page function.
 type Page struct {
-	Title      string
-	Path       string
-	Components []*component.Instance
+	Title       string
+	Path        string
+	Description string
+	Tags        []string          // From a comma-separated tags attribute
+	Date        time.Time         // Publication date; zero if not set
+	Draft       bool              // Drafts are only built on request
+	LastMod     string            // When the page last changed, from the lastmod attribute
+	NoSitemap   bool              // Leave the page out of sitemap.xml, set with sitemap="false"
+	Params      map[string]string // Every other attribute, by name
+	Layout      string            // Qualified name of the component wrapping the page; "" for none
+	Collection  string            // Data path of the list this page is generated once per item of
+	Item        any               // The collection item a generated page is for
+	Paginate    string            // Data path of the list this page is split into pages of
+	PerPage     int               // Items on each page of a paginated page
+	Paginator   *Paginator        // The page of the list a paginated page is rendered for
+	Includes    []string          // Other files the page's content was read from, such as Markdown src files
+	Components  []*component.Instance
+
+	layoutSet bool          // The layout attribute was given, even if empty
+	pos       diag.Position // Position of the <page> element
 }
 
-// LoadPage parses a page XML file into a Page with Component instances
+// slotMarker is the DefinitionName of the placeholder instances parsed from
+// elements with a slot attribute, before they are moved into their parent's slots
+const slotMarker = "__slot__"
+
+// LoadPage parses a page XML file into a Page with Component instances, or a
+// Markdown page if the file has the .md extension. Every component with a
+// registered definition has its attributes validated against the definition's
+// schema. Parse and validation errors are returned as *diag.Error pointing at
+// the offending element.
 func LoadPage(filepath string, registry *component.Registry) (*Page, error) {
 	data, err := os.ReadFile(filepath)
 	if err != nil {
-		return nil, errors.Wrap(err, "reading page file")
+		return nil, errors.Wrapf(errors.Wrap(err, "reading page file"), "page %s", filepath)
+	}
+	return parsePage(data, filepath, registry, osReader(filepath))
+}
+
+// LoadPageFS is LoadPage for a page file within fsys. Errors refer to the page
+// by its name within fsys.
+func LoadPageFS(fsys fs.FS, name string, registry *component.Registry) (*Page, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, errors.Wrapf(errors.Wrap(err, "reading page file"), "page %s", name)
 	}
+	return parsePage(data, name, registry, fsReader(fsys, name))
+}
 
+// parsePage parses the contents of a page file loaded from filepath. Files the
+// page refers to are read with read.
+func parsePage(data []byte, filepath string, registry *component.Registry, read readRelative) (*Page, error) {
+	load := loadPage
+	if strings.HasSuffix(filepath, MarkdownExtension) {
+		load = loadMarkdownPage
+	}
+	page, err := load(data, filepath, registry, read)
+	if err != nil {
+		if _, ok := diag.As(err); ok {
+			return nil, err
+		}
+		return nil, errors.Wrapf(err, "page %s", filepath)
+	}
+	return page, nil
+}
+
+func loadPage(data []byte, filepath string, registry *component.Registry, read readRelative) (*Page, error) {
 	// Parse the XML structure
 	root, err := xmlutil.ParseXML(data)
 	if err != nil {
-		return nil, errors.Wrap(err, "parsing page XML")
+		return nil, diag.InFile(err, filepath)
 	}
 
+	parser := &pageParser{file: filepath, registry: registry, namespaces: namespaceBindings(*root), read: read}
+
 	// Verify root element is "page"
 	if root.XMLName.Local != "page" {
-		return nil, fmt.Errorf("root element must be 'page', got '%s'", root.XMLName.Local)
+		return nil, diag.Errorf(parser.pos(*root), "root element must be 'page', got '%s'", root.XMLName.Local)
 	}
 
 	// Extract page metadata from attributes
 	page := &Page{
+		Params:     map[string]string{},
 		Components: []*component.Instance{},
+		pos:        parser.pos(*root),
 	}
-
-	if title, ok := root.GetAttr("title"); ok {
-		page.Title = title
+	if err := page.setMetadata(root.Attrs); err != nil {
+		return nil, diag.Wrap(page.pos, err)
+	}
+	if layout, ok := root.GetAttr("layout"); ok {
+		if page.Layout, err = parser.qualify(layout, page.pos); err != nil {
+			return nil, err
+		}
+		page.layoutSet = true
 	}
-	if path, ok := root.GetAttr("path"); ok {
-		page.Path = path
+	if err := page.checkCollection(); err != nil {
+		return nil, err
+	}
+	if err := page.checkPagination(); err != nil {
+		return nil, err
 	}
 
 	// Parse child elements as components
-	components, err := parseComponents(root.Content, registry)
+	components, err := parser.parseComponents(root.Children)
 	if err != nil {
-		return nil, errors.Wrap(err, "parsing page components")
+		return nil, err
 	}
 	page.Components = components
+	page.Includes = parser.includes
 
 	return page, nil
 }
 
-// parseComponents parses XML content into Component instances
-func parseComponents(xmlContent []byte, registry *component.Registry) ([]*component.Instance, error) {
-	// Wrap content in a root element for parsing
-	wrappedXML := "<root>" + string(xmlContent) + "</root>"
+// ComponentNames returns the sorted qualified names of every component used on
+// the page, including components nested inside other components
+func (p *Page) ComponentNames() []string {
+	seen := make(map[string]struct{})
+	var walk func(instances []*component.Instance)
+	walk = func(instances []*component.Instance) {
+		for _, inst := range instances {
+			if inst.DefinitionName != "" && inst.DefinitionName != slotMarker {
+				seen[inst.QualifiedName()] = struct{}{}
+			}
+			walk(inst.Children)
+			for _, slot := range inst.Slots {
+				walk(slot)
+			}
+		}
+	}
+	walk(p.Components)
+	if p.Layout != "" {
+		seen[p.Layout] = struct{}{}
+	}
 
-	wrapper, err := xmlutil.ParseXML([]byte(wrappedXML))
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse components: %w", err)
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// pageParser converts the elements of a single page file into Component instances
+type pageParser struct {
+	file       string
+	registry   *component.Registry
+	namespaces map[string]string // Namespace URIs by prefix, from hcmlns:prefix declarations
+	read       readRelative      // Reads files the page refers to
+	includes   []string          // Files read with read, in order
+}
+
+// namespaceBindings returns the namespace URIs bound to prefixes by
+// hcmlns:prefix="uri" attributes on the page element
+func namespaceBindings(root xmlutil.Node) map[string]string {
+	bindings := make(map[string]string)
+	for _, attr := range root.Attrs {
+		if attr.Name.Space == "hcmlns" {
+			bindings[attr.Name.Local] = attr.Value
+		}
 	}
+	return bindings
+}
 
+// defaultPrefix names the default namespace without being declared
+const defaultPrefix = "component"
+
+// namespace resolves the prefix of a component tag. A prefix bound with
+// hcmlns:prefix names that namespace, and an unbound prefix that matches a
+// mounted library names the library. Only the component prefix, or none,
+// means the default namespace; any other prefix is unknown.
+func (pp *pageParser) namespace(prefix string) (string, bool) {
+	if uri, ok := pp.namespaces[prefix]; ok {
+		return uri, true
+	}
+	if pp.registry.HasNamespace(prefix) {
+		return prefix, true
+	}
+	if prefix == "" || prefix == defaultPrefix {
+		return component.DefaultNamespace, true
+	}
+	return "", false
+}
+
+// unknownPrefix reports a prefix that names no namespace, at pos
+func unknownPrefix(pos diag.Position, prefix string) error {
+	return diag.Errorf(pos, "unknown namespace prefix %q: bind it with hcmlns:%s or mount a library named %s", prefix, prefix, prefix)
+}
+
+// qualify resolves a component name written with an optional prefix, as in
+// layout="ui:base", into a qualified name. pos is where the name was written.
+func (pp *pageParser) qualify(name string, pos diag.Position) (string, error) {
+	i := strings.LastIndex(name, ":")
+	if i < 0 {
+		return name, nil
+	}
+	namespace, ok := pp.namespace(name[:i])
+	if !ok {
+		return "", unknownPrefix(pos, name[:i])
+	}
+	return component.QualifiedName(namespace, name[i+1:]), nil
+}
+
+// isNamespaceDeclaration reports whether an attribute declares a namespace
+// rather than being an attribute of the element
+func isNamespaceDeclaration(attr xml.Attr) bool {
+	return attr.Name.Space == "xmlns" || attr.Name.Space == "hcmlns" || attr.Name.Local == "hcmlns"
+}
+
+// pos returns the source position of an element in the page file
+func (pp *pageParser) pos(node xmlutil.Node) diag.Position {
+	return diag.Position{File: pp.file, Line: node.Line, Col: node.Col}
+}
+
+// parseComponents parses XML elements into Component instances
+func (pp *pageParser) parseComponents(nodes []xmlutil.Node) ([]*component.Instance, error) {
 	var components []*component.Instance
-	for _, node := range wrapper.Children {
-		comp, err := parseComponent(node, registry)
+	for _, node := range nodes {
+		comp, err := pp.parseComponent(node)
 		if err != nil {
 			return nil, err
 		}
@@ -83,7 +249,7 @@ func parseComponents(xmlContent []byte, registry *component.Registry) ([]*compon
 }
 
 // parseComponent converts an xmlNode to a Component instance
-func parseComponent(node xmlutil.Node, registry *component.Registry) (*component.Instance, error) {
+func (pp *pageParser) parseComponent(node xmlutil.Node) (*component.Instance, error) {
 	// Extract component name from namespace (e.g., "component:titleBar" -> "titleBar")
 	componentName := node.XMLName.Local
 
@@ -99,12 +265,17 @@ func parseComponent(node xmlutil.Node, registry *component.Registry) (*component
 	if !isComponent {
 		// Check if this element has a "slot" attribute
 		if slotName, hasSlot := node.GetAttr("slot"); hasSlot {
-			// This is a slot element - return only the inner content
+			// This is a slot element - keep only the inner content, parsed
+			// like children so slots can hold components too.
 			// Don't include the wrapper element itself
+			content, err := pp.parseSlotContent(node)
+			if err != nil {
+				return nil, err
+			}
 			return &component.Instance{
-				DefinitionName: "__slot__", // Special marker
+				DefinitionName: slotMarker,
 				Attributes:     map[string]string{"name": slotName},
-				RawHTML:        string(node.Content), // Just the inner content
+				Children:       content,
 			}, nil
 		}
 
@@ -118,36 +289,55 @@ func parseComponent(node xmlutil.Node, registry *component.Registry) (*component
 		}, nil
 	}
 
+	if pp.isMarkdown(node) {
+		return pp.parseMarkdown(node)
+	}
+
+	namespace, ok := pp.namespace(node.XMLName.Space)
+	if !ok {
+		return nil, unknownPrefix(pp.pos(node), node.XMLName.Space)
+	}
+
 	// Create component instance
 	comp := &component.Instance{
 		DefinitionName: componentName,
+		Namespace:      namespace,
+		Pos:            pp.pos(node),
 		Attributes:     make(map[string]string),
 		Children:       []*component.Instance{},
-		Slots:          make(map[string]string),
+		Slots:          make(map[string][]*component.Instance),
 	}
 
 	// Extract attributes
 	for _, attr := range node.Attrs {
-		// Skip namespace declarations
-		if attr.Name.Space == "xmlns" || attr.Name.Local == "hcmlns" {
+		if isNamespaceDeclaration(attr) {
 			continue
 		}
 		comp.Attributes[attr.Name.Local] = attr.Value
 	}
 
+	// Check attributes against the definition's schema. Unknown components are
+	// reported when the page is generated.
+	def := pp.registry.Resolve(comp.Namespace, componentName)
+	if def != nil {
+		if err := def.ValidateAttributes(comp.Attributes); err != nil {
+			return nil, diag.Wrap(comp.Pos, err)
+		}
+	}
+
 	// Parse nested components recursively
-	if len(node.Content) > 0 {
-		children, err := parseComponents(node.Content, registry)
+	if len(node.Children) > 0 {
+		children, err := pp.parseComponents(node.Children)
 		if err != nil {
 			return nil, err
 		}
 
 		// Separate slots from regular children
 		for _, child := range children {
-			// Check if this child is a slot (marked with __slot__ DefinitionName)
-			if child.DefinitionName == "__slot__" {
+			// Check if this child is a slot placeholder
+			if child.DefinitionName == slotMarker {
 				slotName := child.Attributes["name"]
-				comp.Slots[slotName] = child.RawHTML
+				comp.Slots[slotName] = append(comp.Slots[slotName], child.Children...)
 				continue
 			}
 			// Regular child
@@ -155,9 +345,43 @@ func parseComponent(node xmlutil.Node, registry *component.Registry) (*component
 		}
 	}
 
+	if def != nil {
+		if err := def.ValidateSlots(comp.Slots); err != nil {
+			return nil, diag.Wrap(comp.Pos, err)
+		}
+	}
+
 	return comp, nil
 }
 
+// parseSlotContent parses the inner content of a slot element. Child elements
+// are parsed like children, so slots can hold components too, and the text
+// around them is kept as raw HTML.
+func (pp *pageParser) parseSlotContent(node xmlutil.Node) ([]*component.Instance, error) {
+	if strings.TrimSpace(string(node.Content)) == "" {
+		return nil, nil
+	}
+
+	var content []*component.Instance
+	text := node.Text()
+	for i, child := range node.Children {
+		if len(text[i]) > 0 {
+			content = append(content, &component.Instance{RawHTML: string(text[i])})
+		}
+		comp, err := pp.parseComponent(child)
+		if err != nil {
+			return nil, err
+		}
+		if comp != nil {
+			content = append(content, comp)
+		}
+	}
+	if last := text[len(text)-1]; len(last) > 0 {
+		content = append(content, &component.Instance{RawHTML: string(last)})
+	}
+	return content, nil
+}
+
 // reconstructHTML rebuilds HTML from an XML node
 func reconstructHTML(node xmlutil.Node) (string, error) {
 	var html strings.Builder
@@ -168,8 +392,7 @@ func reconstructHTML(node xmlutil.Node) (string, error) {
 
 	// Add attributes
 	for _, attr := range node.Attrs {
-		// Skip namespace declarations
-		if attr.Name.Space == "xmlns" || attr.Name.Local == "hcmlns" {
+		if isNamespaceDeclaration(attr) {
 			continue
 		}
 		html.WriteString(" ")
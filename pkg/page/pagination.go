@@ -0,0 +1,63 @@
+package page
+
+import "github.com/blamarvt/staticgen/pkg/diag"
+
+// DefaultPerPage is the page size of paginated pages that don't set per_page
+const DefaultPerPage = 10
+
+// Paginator describes one page of a paginated list. Templates read it as
+// Page.Paginator, e.g. {{ range Page.Paginator.Items }}.
+type Paginator struct {
+	Page       int    // Current page number, starting at 1
+	Total      int    // Number of pages
+	PerPage    int    // Items per page
+	TotalItems int    // Items across every page
+	Items      []any  // Items on the current page
+	URL        string // URL of the current page
+	First      string // URL of the first page
+	Last       string // URL of the last page
+	Prev       string // URL of the previous page; "" on the first page
+	Next       string // URL of the next page; "" on the last page
+}
+
+// checkPagination makes sure a paginated page has a usable page size and
+// isn't also a collection
+func (p *Page) checkPagination() error {
+	if p.Paginate == "" {
+		if p.PerPage != 0 {
+			return diag.Errorf(p.pos, "per_page needs a list to paginate, set with paginate")
+		}
+		return nil
+	}
+	if p.Collection != "" {
+		return diag.Errorf(p.pos, "a page can't be both a collection and paginated")
+	}
+	if p.PerPage == 0 {
+		p.PerPage = DefaultPerPage
+	}
+	return nil
+}
+
+// Pages returns the number of pages needed to list count items. An empty list
+// still has one page.
+func (p *Page) Pages(count int) int {
+	if count == 0 {
+		return 1
+	}
+	return (count + p.PerPage - 1) / p.PerPage
+}
+
+// ForPage returns the page rendered for one page of its paginated list.
+// paginator.Page selects which of items the page lists.
+func (p *Page) ForPage(items []any, paginator Paginator) *Page {
+	start := min((paginator.Page-1)*p.PerPage, len(items))
+	end := min(start+p.PerPage, len(items))
+
+	paginator.PerPage = p.PerPage
+	paginator.TotalItems = len(items)
+	paginator.Items = items[start:end]
+
+	expanded := *p
+	expanded.Paginator = &paginator
+	return &expanded
+}
@@ -0,0 +1,128 @@
+package site
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/blamarvt/staticgen/pkg/assets"
+	"github.com/blamarvt/staticgen/pkg/buildcache"
+	"github.com/blamarvt/staticgen/pkg/component"
+)
+
+// siteAssets are the static assets copied by a build
+type siteAssets struct {
+	dir      string // Static directory the assets were copied from
+	manifest assets.Manifest
+}
+
+// track returns a resolver for a single page that records which assets the
+// page's templates asked for. Without static assets it returns nil, so the
+// Asset template function reports that none are configured.
+func (a *siteAssets) track() *assetTracker {
+	if a == nil {
+		return nil
+	}
+	return &assetTracker{assets: a, used: make(map[string]string)}
+}
+
+// assetTracker resolves asset URLs for one page. Pages are rendered by a
+// single goroutine, so it needs no locking.
+type assetTracker struct {
+	assets *siteAssets
+	used   map[string]string // Asset name -> output path
+}
+
+// AssetURL resolves name from the manifest and records it as used
+func (t *assetTracker) AssetURL(name string) (string, error) {
+	url, err := t.assets.manifest.AssetURL(name)
+	if err == nil {
+		name = strings.TrimPrefix(name, "/")
+		t.used[name] = t.assets.manifest[name]
+	}
+	return url, err
+}
+
+// Outputs returns the output path every asset used resolved to, by name
+func (t *assetTracker) Outputs() map[string]string {
+	if t == nil {
+		return nil
+	}
+	return t.used
+}
+
+// Sources returns the static files of every asset used, sorted
+func (t *assetTracker) Sources() []string {
+	if t == nil {
+		return nil
+	}
+	sources := make([]string, 0, len(t.used))
+	for name := range t.used {
+		sources = append(sources, filepath.Join(t.assets.dir, filepath.FromSlash(name)))
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+// context returns the component context for a page rendered with variables
+// and asset tracker t
+func (t *assetTracker) context(ctx *component.Context) *component.Context {
+	// A nil *assetTracker must not become a non-nil AssetResolver
+	if t != nil {
+		ctx.Assets = t
+	}
+	return ctx
+}
+
+// previousAssets returns the static files copied by the previous build, so
+// files deleted since then can be removed. They come from the build cache and
+// the manifest left in the output directory, so deletions are also mirrored
+// without a cache.
+func previousAssets(outDir string, stored *buildcache.Cache) []string {
+	files := slices.Clone(stored.Assets)
+	if manifest, err := assets.ReadManifest(filepath.Join(outDir, assets.ManifestFile)); err == nil {
+		files = append(files, manifest.Files()...)
+	}
+	slices.Sort(files)
+	return slices.Compact(files)
+}
+
+// writeManifest saves the asset manifest in the output directory. Sites
+// without static assets get no manifest.
+func writeManifest(outDir string, manifest assets.Manifest) error {
+	path := filepath.Join(outDir, assets.ManifestFile)
+	if len(manifest) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return err
+	}
+	return manifest.Write(path)
+}
+
+// output returns the output path of the asset name, or "" if there is no
+// such asset
+func (a *siteAssets) output(name string) string {
+	if a == nil {
+		return ""
+	}
+	return a.manifest[name]
+}
+
+// owners maps the output path of every static asset to its source file
+func (a *siteAssets) owners(outDir string) map[string]string {
+	owners := make(map[string]string)
+	if a == nil {
+		return owners
+	}
+	for name, out := range a.manifest {
+		owners[filepath.Join(outDir, filepath.FromSlash(out))] = filepath.Join(a.dir, filepath.FromSlash(name))
+	}
+	return owners
+}
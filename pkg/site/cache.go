@@ -0,0 +1,86 @@
+package site
+
+import (
+	"maps"
+	"os"
+
+	"github.com/blamarvt/staticgen/pkg/buildcache"
+	"github.com/blamarvt/staticgen/pkg/component"
+	"github.com/blamarvt/staticgen/pkg/page"
+	"github.com/blamarvt/staticgen/pkg/vars"
+)
+
+// recordEntry captures the inputs a freshly generated page depended on: its
+// source file, the files it included, the static assets and data files it
+// read, every component it used, every variable its templates read, where the
+// assets it linked to were written and the layout it was wrapped in
+func recordEntry(source string, p *page.Page, registry *component.Registry, tracked *vars.Store, files []string, assets map[string]string) (*buildcache.Entry, error) {
+	entry := buildcache.NewEntry(source)
+	entry.Layout = p.Layout
+	maps.Copy(entry.Assets, assets)
+
+	for _, path := range append([]string{source}, files...) {
+		hash, err := buildcache.HashFile(path)
+		if err != nil {
+			return nil, err
+		}
+		entry.Files[path] = hash
+	}
+
+	for _, name := range p.ComponentNames() {
+		entry.Components[name] = componentChecksum(registry, name)
+	}
+	for _, name := range tracked.Accessed() {
+		entry.Variables[name] = variableHash(tracked, name)
+	}
+
+	return entry, nil
+}
+
+// upToDate reports whether a page's output still exists and was generated
+// from the same source, the page is wrapped in the same layout, the assets it
+// linked to are still written to the same paths, and every input recorded in
+// its previous entry hashes to the same value today
+func upToDate(previous *buildcache.Entry, source, outPath string, p *page.Page, registry *component.Registry, variables *vars.Store, static *siteAssets) bool {
+	if previous.Source != source {
+		return false
+	}
+	if _, err := os.Stat(outPath); err != nil {
+		return false
+	}
+
+	current := buildcache.NewEntry(source)
+	current.Layout = p.Layout
+	for path := range previous.Files {
+		// Unreadable files hash to "" and so never match
+		current.Files[path], _ = buildcache.HashFile(path)
+	}
+	for name := range previous.Components {
+		current.Components[name] = componentChecksum(registry, name)
+	}
+	for name := range previous.Variables {
+		current.Variables[name] = variableHash(variables, name)
+	}
+	for name := range previous.Assets {
+		current.Assets[name] = static.output(name)
+	}
+
+	return current.Equal(previous)
+}
+
+// componentChecksum returns the checksum of a registered definition, or "" if
+// the component no longer exists
+func componentChecksum(registry *component.Registry, name string) string {
+	if def := registry.Get(name); def != nil {
+		return def.Checksum
+	}
+	return ""
+}
+
+// variableHash returns the hash of a variable's value, or "" if it is unset
+func variableHash(variables *vars.Store, name string) string {
+	if value, ok := variables.Get(name); ok {
+		return buildcache.Hash([]byte(value))
+	}
+	return ""
+}
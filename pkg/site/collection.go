@@ -0,0 +1,66 @@
+package site
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/blamarvt/staticgen/pkg/data"
+)
+
+// expandPages replaces every collection page with one job per item of its
+// collection, in the order the items are listed, and every paginated page
+// with one job per page of its list. Other jobs are kept as they are.
+func expandPages(opts Options, jobs []*pageJob, siteData *data.Tree) []*pageJob {
+	expanded := make([]*pageJob, 0, len(jobs))
+	for _, job := range jobs {
+		switch {
+		case job.err != nil || job.skipped || job.draft:
+			expanded = append(expanded, job)
+		case job.page.Collection != "":
+			expanded = append(expanded, job.expand(opts, siteData)...)
+		case job.page.Paginate != "":
+			expanded = append(expanded, job.paginate(opts, siteData)...)
+		default:
+			expanded = append(expanded, job)
+		}
+	}
+	return expanded
+}
+
+// expand creates a job for every item of the page's collection. If the
+// collection can't be expanded, the page fails as a whole.
+func (j *pageJob) expand(opts Options, siteData *data.Tree) []*pageJob {
+	fail := func(err error) []*pageJob {
+		j.err = &PageError{Source: j.source, Kind: DataError, Err: err}
+		return []*pageJob{j}
+	}
+
+	value, err := siteData.Lookup(j.page.Collection)
+	if err != nil {
+		return fail(errors.Wrapf(err, "page %s: collection", j.source))
+	}
+	items, ok := value.([]any)
+	if !ok {
+		return fail(fmt.Errorf("page %s: collection %q is not a list", j.source, j.page.Collection))
+	}
+
+	jobs := make([]*pageJob, 0, len(items))
+	for _, item := range items {
+		p, err := j.page.ForItem(item)
+		if err != nil {
+			return fail(err)
+		}
+		outPath, err := outputPath(opts, j.source, p)
+		if err != nil {
+			j.err = &PageError{Source: j.source, Kind: WriteError, Err: err}
+			return []*pageJob{j}
+		}
+		if rel, err := filepath.Rel(opts.OutputDir, outPath); err != nil || !filepath.IsLocal(rel) {
+			return fail(fmt.Errorf("page %s: collection item path %s is outside the output directory", j.source, p.Path))
+		}
+		jobs = append(jobs, &pageJob{source: j.source, page: p, outPath: outPath})
+	}
+	return jobs
+}
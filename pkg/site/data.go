@@ -0,0 +1,51 @@
+package site
+
+import (
+	"slices"
+
+	"github.com/blamarvt/staticgen/pkg/component"
+	"github.com/blamarvt/staticgen/pkg/data"
+)
+
+// dataTracker looks up site data for one page and records which data files
+// the page's templates read. Pages are rendered by a single goroutine, so it
+// needs no locking.
+type dataTracker struct {
+	tree *data.Tree
+	used map[string]bool
+}
+
+// trackData returns a tracker for a single page reading from tree
+func trackData(tree *data.Tree) *dataTracker {
+	return &dataTracker{tree: tree, used: make(map[string]bool)}
+}
+
+// Lookup resolves path in the data tree and records the files it came from
+func (t *dataTracker) Lookup(path string) (any, error) {
+	t.record(path)
+	return t.tree.Lookup(path)
+}
+
+// record marks the files a lookup of path reads from as used, without
+// looking it up
+func (t *dataTracker) record(path string) {
+	for _, file := range t.tree.Files(path) {
+		t.used[file] = true
+	}
+}
+
+// Sources returns every data file read, sorted
+func (t *dataTracker) Sources() []string {
+	sources := make([]string, 0, len(t.used))
+	for file := range t.used {
+		sources = append(sources, file)
+	}
+	slices.Sort(sources)
+	return sources
+}
+
+// context adds the tracker to a page's component context
+func (t *dataTracker) context(ctx *component.Context) *component.Context {
+	ctx.Data = t
+	return ctx
+}
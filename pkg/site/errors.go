@@ -0,0 +1,115 @@
+package site
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/blamarvt/staticgen/pkg/component"
+)
+
+// ErrorKind classifies the stage of the build a page failed in
+type ErrorKind string
+
+const (
+	ParseError       ErrorKind = "parse"             // The page file could not be read or parsed
+	ValidationError  ErrorKind = "validation"        // Attributes or slots don't match a component's schema
+	MissingComponent ErrorKind = "missing component" // The page uses a component that isn't registered
+	DataError        ErrorKind = "data"              // A collection or paginated page's list is missing or doesn't fit the page
+	TemplateError    ErrorKind = "template"          // A component template failed to execute
+	WriteError       ErrorKind = "write"             // The output could not be written
+)
+
+// errorKinds lists every kind in the order summaries report them
+var errorKinds = []ErrorKind{ParseError, ValidationError, MissingComponent, DataError, TemplateError, WriteError}
+
+// PageError is the failure of a single page
+type PageError struct {
+	Source string
+	Kind   ErrorKind
+	Err    error
+}
+
+func (e *PageError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PageError) Unwrap() error {
+	return e.Err
+}
+
+// loadError classifies a failure to load a page
+func loadError(source string, err error) *PageError {
+	if isValidationError(err) {
+		return &PageError{Source: source, Kind: ValidationError, Err: err}
+	}
+	return &PageError{Source: source, Kind: ParseError, Err: err}
+}
+
+// renderError classifies a failure to render a page. Layouts are only
+// validated when the page is rendered.
+func renderError(source string, err error) *PageError {
+	switch {
+	case errors.Is(err, component.ErrNotFound):
+		return &PageError{Source: source, Kind: MissingComponent, Err: err}
+	case isValidationError(err):
+		return &PageError{Source: source, Kind: ValidationError, Err: err}
+	}
+	return &PageError{Source: source, Kind: TemplateError, Err: err}
+}
+
+// isValidationError reports whether err is an attribute or slot that doesn't
+// match a component's schema
+func isValidationError(err error) bool {
+	var attrErr *component.AttributeError
+	var slotErr *component.SlotError
+	return errors.As(err, &attrErr) || errors.As(err, &slotErr)
+}
+
+// Errors collects the failure of every page that could not be built
+type Errors []*PageError
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Unwrap exposes the individual failures to errors.Is and errors.As
+func (e Errors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+// Counts returns the number of failures of each kind
+func (e Errors) Counts() map[ErrorKind]int {
+	counts := make(map[ErrorKind]int)
+	for _, err := range e {
+		counts[err.Kind]++
+	}
+	return counts
+}
+
+// Summary describes how many failures of each kind occurred, e.g.
+// "3 errors (1 parse, 2 template)"
+func (e Errors) Summary() string {
+	counts := e.Counts()
+	var parts []string
+	for _, kind := range errorKinds {
+		if counts[kind] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[kind], kind))
+		}
+	}
+
+	noun := "errors"
+	if len(e) == 1 {
+		noun = "error"
+	}
+	return fmt.Sprintf("%d %s (%s)", len(e), noun, strings.Join(parts, ", "))
+}
@@ -0,0 +1,233 @@
+package site
+
+import (
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/blamarvt/staticgen/pkg/vars"
+)
+
+// Output paths of a feed that sets neither rss nor atom
+const (
+	DefaultRSSFile  = "feed.xml"
+	DefaultAtomFile = "atom.xml"
+)
+
+// atomNamespace is the XML namespace of Atom feeds
+const atomNamespace = "http://www.w3.org/2005/Atom"
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Self          atomLink  `xml:"atom:link"` // URL the feed itself is served at
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	GUID        string `xml:"guid"`
+	PubDate     string `xml:"pubDate"`
+	Description string `xml:"description,omitempty"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	Xmlns   string      `xml:"xmlns,attr"`
+	Title   string      `xml:"title"`
+	Links   []atomLink  `xml:"link"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	Title   string   `xml:"title"`
+	Link    atomLink `xml:"link"`
+	ID      string   `xml:"id"`
+	Updated string   `xml:"updated"`
+	Summary string   `xml:"summary,omitempty"`
+}
+
+// feedItem is a page listed in a feed
+type feedItem struct {
+	title   string
+	link    string
+	summary string
+	date    time.Time
+}
+
+// writeFeeds writes the RSS and Atom files of every configured feed. Feeds
+// only depend on the pages built, so the same pages always give the same
+// bytes.
+func writeFeeds(opts Options, jobs []*pageJob, static *siteAssets) error {
+	owners := static.owners(opts.OutputDir)
+	for _, job := range jobs {
+		if job.outPath != "" && job.err == nil {
+			owners[job.outPath] = job.source
+		}
+	}
+
+	for _, feed := range opts.Feeds {
+		items, err := feedItems(opts, feed, jobs)
+		if err != nil {
+			return err
+		}
+
+		rss, atom := feed.RSS, feed.Atom
+		if rss == "" && atom == "" {
+			rss, atom = DefaultRSSFile, DefaultAtomFile
+		}
+		for _, file := range []struct {
+			name  string
+			build func(feed vars.Feed, site, self string, items []feedItem) any
+		}{{rss, rssDocument}, {atom, atomDocument}} {
+			if file.name == "" {
+				continue
+			}
+			path := filepath.Join(opts.OutputDir, filepath.FromSlash(file.name))
+			if owner, ok := owners[path]; ok {
+				return fmt.Errorf("feed %s is generated but also provided by %s", file.name, owner)
+			}
+			owners[path] = "another feed"
+
+			doc := file.build(feed, siteURL(opts.BaseURL, ""), siteURL(opts.BaseURL, strings.TrimPrefix(file.name, "/")), items)
+			if err := writeXML(path, doc); err != nil {
+				return errors.Wrap(err, "writing feed "+file.name)
+			}
+		}
+	}
+	return nil
+}
+
+// feedItems returns the dated pages a feed lists, newest first. Pages from
+// the same date are ordered by URL so the order never depends on the build.
+func feedItems(opts Options, feed vars.Feed, jobs []*pageJob) ([]feedItem, error) {
+	var items []feedItem
+	for _, job := range jobs {
+		if job.err != nil || job.skipped || job.draft || job.page.Date.IsZero() {
+			continue
+		}
+		if feed.Tag != "" && !job.page.HasTag(feed.Tag) {
+			continue
+		}
+		if feed.Dir != "" && !inDir(filepath.Join(opts.PagesDir, filepath.FromSlash(feed.Dir)), job.source) {
+			continue
+		}
+
+		rel, err := filepath.Rel(opts.OutputDir, job.outPath)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, feedItem{
+			title:   job.page.Title,
+			link:    siteURL(opts.BaseURL, pageURLPath(rel)),
+			summary: job.page.Description,
+			date:    job.page.Date,
+		})
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].date.Equal(items[j].date) {
+			return items[i].date.After(items[j].date)
+		}
+		return items[i].link < items[j].link
+	})
+	if feed.Limit > 0 && len(items) > feed.Limit {
+		items = items[:feed.Limit]
+	}
+	return items, nil
+}
+
+// inDir reports whether path is inside dir
+func inDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// feedUpdated is when a feed last changed: the date of its newest page, or
+// the Unix epoch for an empty feed, never the time of the build
+func feedUpdated(items []feedItem) time.Time {
+	if len(items) == 0 {
+		return time.Unix(0, 0).UTC()
+	}
+	return items[0].date.UTC()
+}
+
+// rssDocument builds the RSS 2.0 document of a feed of site served at self
+func rssDocument(feed vars.Feed, site, self string, items []feedItem) any {
+	description := feed.Description
+	if description == "" {
+		description = feed.Title
+	}
+	doc := rssFeed{Version: "2.0", Atom: atomNamespace, Channel: rssChannel{
+		Title:         feed.Title,
+		Link:          site,
+		Self:          atomLink{Href: self, Rel: "self", Type: "application/rss+xml"},
+		Description:   description,
+		LastBuildDate: feedUpdated(items).Format(time.RFC1123Z),
+	}}
+	for _, item := range items {
+		doc.Channel.Items = append(doc.Channel.Items, rssItem{
+			Title:       item.title,
+			Link:        item.link,
+			GUID:        item.link,
+			PubDate:     item.date.UTC().Format(time.RFC1123Z),
+			Description: item.summary,
+		})
+	}
+	return doc
+}
+
+// atomDocument builds the Atom document of a feed of site served at self.
+// Atom requires an author, so a feed without one is credited to its title.
+func atomDocument(feed vars.Feed, site, self string, items []feedItem) any {
+	author := feed.Author
+	if author == "" {
+		author = feed.Title
+	}
+	doc := atomFeed{
+		Xmlns:   atomNamespace,
+		Title:   feed.Title,
+		Links:   []atomLink{{Href: site}, {Href: self, Rel: "self"}},
+		ID:      self,
+		Updated: feedUpdated(items).Format(time.RFC3339),
+		Author:  atomAuthor{Name: author},
+	}
+	for _, item := range items {
+		doc.Entries = append(doc.Entries, atomEntry{
+			Title:   item.title,
+			Link:    atomLink{Href: item.link},
+			ID:      item.link,
+			Updated: item.date.UTC().Format(time.RFC3339),
+			Summary: item.summary,
+		})
+	}
+	return doc
+}
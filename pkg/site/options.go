@@ -0,0 +1,154 @@
+package site
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/blamarvt/staticgen/pkg/component"
+	"github.com/blamarvt/staticgen/pkg/page"
+	"github.com/blamarvt/staticgen/pkg/vars"
+)
+
+// Default project layout, used for anything the config file leaves out
+const (
+	DefaultComponentsDir = "templates"
+	DefaultPagesDir      = "pages"
+	DefaultOutputDir     = "dist"
+	DefaultStaticDir     = "static"
+	DefaultDataDir       = "data"
+)
+
+// DefaultPageExtensions are the file extensions treated as pages
+var DefaultPageExtensions = []string{".hcml", page.MarkdownExtension}
+
+// DefaultOptions returns the options for the default project layout
+func DefaultOptions() Options {
+	return Options{
+		ComponentsDirs:      []string{DefaultComponentsDir},
+		PagesDir:            DefaultPagesDir,
+		OutputDir:           DefaultOutputDir,
+		StaticDir:           DefaultStaticDir,
+		DataDir:             DefaultDataDir,
+		PageExtensions:      DefaultPageExtensions,
+		ComponentExtensions: component.DefaultExtensions,
+	}
+}
+
+// LoadOptions returns the default options overridden by the layout in the
+// config file. Relative directories in the config file are resolved against
+// the directory containing it. A missing config file leaves the defaults
+// unchanged.
+func LoadOptions(configFile string) (Options, error) {
+	opts := DefaultOptions()
+	opts.ConfigFile = configFile
+
+	config, err := vars.LoadConfig(configFile)
+	if os.IsNotExist(err) {
+		return opts, nil
+	}
+	if err != nil {
+		return opts, errors.Wrap(err, "loading config file "+configFile)
+	}
+
+	opts.ApplyLayout(config.Layout, filepath.Dir(configFile))
+	opts.BaseURL = config.BaseURL
+	opts.Sitemap = config.Sitemap
+	opts.Robots = config.Robots
+	opts.Layout = config.PageLayout
+	opts.Feeds = config.Feeds
+	return opts, nil
+}
+
+// ApplyLayout overrides options with every field set in layout. Relative
+// directories are resolved against base.
+func (o *Options) ApplyLayout(layout vars.Layout, base string) {
+	resolve := func(dir string) string {
+		if filepath.IsAbs(dir) {
+			return dir
+		}
+		return filepath.Join(base, dir)
+	}
+
+	if len(layout.Components) > 0 {
+		o.ComponentsDir = ""
+		o.ComponentsDirs = nil
+		for _, dir := range layout.Components {
+			o.ComponentsDirs = append(o.ComponentsDirs, resolve(dir))
+		}
+	}
+	if len(layout.Libraries) > 0 {
+		o.Libraries = make(map[string][]string, len(layout.Libraries))
+		for namespace, dirs := range layout.Libraries {
+			for _, dir := range dirs {
+				o.Libraries[namespace] = append(o.Libraries[namespace], resolve(dir))
+			}
+		}
+	}
+	if layout.Pages != "" {
+		o.PagesDir = resolve(layout.Pages)
+	}
+	if layout.Output != "" {
+		o.OutputDir = resolve(layout.Output)
+	}
+	if layout.Static != "" {
+		o.StaticDir = resolve(layout.Static)
+	}
+	if layout.Data != "" {
+		o.DataDir = resolve(layout.Data)
+	}
+	if len(layout.PageExtensions) > 0 {
+		o.PageExtensions = normalizeExtensions(layout.PageExtensions)
+	}
+	if len(layout.ComponentExtensions) > 0 {
+		o.ComponentExtensions = normalizeExtensions(layout.ComponentExtensions)
+	}
+	if len(layout.Fingerprint) > 0 {
+		o.Fingerprint = normalizeExtensions(layout.Fingerprint)
+	}
+}
+
+// componentDirs returns every directory components are loaded from, outside
+// of libraries
+func (o *Options) componentDirs() []string {
+	if o.ComponentsDir == "" {
+		return o.ComponentsDirs
+	}
+	return append([]string{o.ComponentsDir}, o.ComponentsDirs...)
+}
+
+// WatchPaths returns every file and directory the build reads from, sorted
+func (o *Options) WatchPaths() []string {
+	paths := slices.Clone(o.componentDirs())
+	for _, dirs := range o.Libraries {
+		paths = append(paths, dirs...)
+	}
+	paths = append(paths, o.PagesDir, o.StaticDir, o.DataDir, o.ConfigFile)
+	paths = slices.DeleteFunc(paths, func(path string) bool { return path == "" })
+	slices.Sort(paths)
+	return slices.Compact(paths)
+}
+
+// pageExtensions returns the extensions of page files
+func (o *Options) pageExtensions() []string {
+	if len(o.PageExtensions) == 0 {
+		return DefaultPageExtensions
+	}
+	return o.PageExtensions
+}
+
+// normalizeExtensions makes sure every extension starts with a dot, so both
+// "hcml" and ".hcml" are accepted
+func normalizeExtensions(extensions []string) []string {
+	normalized := make([]string, len(extensions))
+	for i, ext := range extensions {
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		normalized[i] = ext
+	}
+	return normalized
+}
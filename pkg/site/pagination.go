@@ -0,0 +1,74 @@
+package site
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/blamarvt/staticgen/pkg/data"
+	"github.com/blamarvt/staticgen/pkg/page"
+)
+
+// paginate creates a job for every page of the page's list. The first page is
+// written to the page's own output path and later ones below it, so
+// blog/index.html continues at blog/page/2/index.html. If the list can't be
+// paginated, the page fails as a whole.
+func (j *pageJob) paginate(opts Options, siteData *data.Tree) []*pageJob {
+	fail := func(err error) []*pageJob {
+		j.err = &PageError{Source: j.source, Kind: DataError, Err: err}
+		return []*pageJob{j}
+	}
+
+	value, err := siteData.Lookup(j.page.Paginate)
+	if err != nil {
+		return fail(errors.Wrapf(err, "page %s: paginate", j.source))
+	}
+	items, ok := value.([]any)
+	if !ok {
+		return fail(fmt.Errorf("page %s: paginate %q is not a list", j.source, j.page.Paginate))
+	}
+
+	total := j.page.Pages(len(items))
+	outPaths := make([]string, total)
+	urls := make([]string, total)
+	for i := range total {
+		outPaths[i] = paginatedPath(j.outPath, i+1)
+		rel, err := filepath.Rel(opts.OutputDir, outPaths[i])
+		if err != nil {
+			j.err = &PageError{Source: j.source, Kind: WriteError, Err: err}
+			return []*pageJob{j}
+		}
+		urls[i] = "/" + pageURLPath(rel)
+	}
+
+	jobs := make([]*pageJob, total)
+	for i := range total {
+		paginator := page.Paginator{Page: i + 1, Total: total, URL: urls[i], First: urls[0], Last: urls[total-1]}
+		if i > 0 {
+			paginator.Prev = urls[i-1]
+		}
+		if i < total-1 {
+			paginator.Next = urls[i+1]
+		}
+		jobs[i] = &pageJob{source: j.source, page: j.page.ForPage(items, paginator), outPath: outPaths[i]}
+	}
+	return jobs
+}
+
+// paginatedPath returns the output path of page n of a list whose first page
+// is written to first. Later pages go in a page directory next to an index.html
+// first page, or named after any other first page, so both blog/index.html
+// and blog.html continue at blog/page/2/index.html.
+func paginatedPath(first string, n int) string {
+	if n == 1 {
+		return first
+	}
+	base := strings.TrimSuffix(first, filepath.Ext(first))
+	if filepath.Base(first) == "index.html" {
+		base = filepath.Dir(first)
+	}
+	return filepath.Join(base, "page", strconv.Itoa(n), "index.html")
+}
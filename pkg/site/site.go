@@ -0,0 +1,419 @@
+package site
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+
+	"github.com/blamarvt/staticgen/pkg/assets"
+	"github.com/blamarvt/staticgen/pkg/buildcache"
+	"github.com/blamarvt/staticgen/pkg/component"
+	"github.com/blamarvt/staticgen/pkg/data"
+	"github.com/blamarvt/staticgen/pkg/diag"
+	"github.com/blamarvt/staticgen/pkg/page"
+	"github.com/blamarvt/staticgen/pkg/vars"
+)
+
+// Options configures a site build
+type Options struct {
+	ComponentsDir       string              // Directory containing component definitions
+	ComponentsDirs      []string            // Further component directories, loaded after ComponentsDir
+	Libraries           map[string][]string // Component directories mounted under a namespace, by namespace
+	LibraryFS           map[string]fs.FS    // Component libraries in file systems such as embed.FS, by namespace
+	PagesDir            string              // Directory containing page files
+	OutputDir           string              // Directory generated pages are written to
+	StaticDir           string              // Directory of static assets mirrored into the output; empty disables copying
+	DataDir             string              // Directory of YAML, JSON and CSV files available to templates with Data
+	Fingerprint         []string            // Extensions of static assets renamed to include a content hash
+	ConfigFile          string              // Path to the .staticgen.yml configuration file
+	CacheFile           string              // Build cache used to skip unchanged pages; empty disables it
+	Force               bool                // Regenerate every page even if the cache says it is unchanged
+	Jobs                int                 // Pages generated concurrently; defaults to GOMAXPROCS
+	FailFast            bool                // Stop starting new pages after the first failure
+	Drafts              bool                // Build pages marked draft="true", which are left out otherwise
+	Layout              string              // Component wrapping pages that don't set a layout attribute; empty for none
+	BaseURL             string              // Absolute URL the site is published at, used by the sitemap
+	Sitemap             bool                // Write sitemap.xml listing every page; requires BaseURL
+	Robots              bool                // Write a robots.txt pointing at the sitemap, unless the static directory has one
+	Feeds               []vars.Feed         // RSS and Atom feeds of dated pages; require BaseURL
+	PageExtensions      []string            // Extensions of page files; defaults to DefaultPageExtensions
+	ComponentExtensions []string            // Extensions of component files; defaults to component.DefaultExtensions
+}
+
+// Result summarizes a completed build
+type Result struct {
+	Generated int // Pages rendered and written
+	Unchanged int // Pages skipped because none of their inputs changed
+	Failed    int // Pages that could not be built
+	Skipped   int // Pages never attempted because FailFast stopped the build
+	Drafts    int // Draft pages left out of the build
+
+	AssetsCopied    int // Static assets copied because they were new or changed
+	AssetsUnchanged int // Static assets whose output copy was up to date
+	AssetsRemoved   int // Static assets removed because they were deleted upstream
+}
+
+// Total returns the number of pages found
+func (r *Result) Total() int {
+	return r.Generated + r.Unchanged + r.Failed + r.Skipped + r.Drafts
+}
+
+// pageJob tracks a single page through the build
+type pageJob struct {
+	source    string
+	page      *page.Page
+	outPath   string
+	entry     *buildcache.Entry
+	unchanged bool
+	skipped   bool
+	draft     bool // Left out because it is a draft
+	err       *PageError
+}
+
+// Build loads every component definition, mirrors the static directory and
+// generates each page into the output directory. With a cache file
+// configured, pages whose recorded inputs are unchanged since the previous
+// build are skipped, and static assets deleted since then are removed.
+//
+// Pages are generated concurrently. A failing page does not stop the build
+// unless FailFast is set; every failure is returned together as Errors once
+// all pages are processed, alongside the Result for the pages that succeeded.
+func Build(opts Options) (*Result, error) {
+	if opts.Sitemap {
+		if err := checkBaseURL(opts.BaseURL, "sitemap"); err != nil {
+			return nil, err
+		}
+	}
+	if len(opts.Feeds) > 0 {
+		if err := checkBaseURL(opts.BaseURL, "feeds"); err != nil {
+			return nil, err
+		}
+	}
+
+	registry := component.NewRegistry()
+	for _, dir := range opts.componentDirs() {
+		if err := registry.LoadAll(dir, opts.ComponentExtensions...); err != nil {
+			if _, ok := diag.As(err); ok {
+				return nil, err
+			}
+			return nil, errors.Wrap(err, "loading components")
+		}
+	}
+	for _, namespace := range slices.Sorted(maps.Keys(opts.Libraries)) {
+		for _, dir := range opts.Libraries[namespace] {
+			if err := registry.LoadLibrary(dir, namespace, opts.ComponentExtensions...); err != nil {
+				if _, ok := diag.As(err); ok {
+					return nil, err
+				}
+				return nil, errors.Wrapf(err, "loading component library %s", namespace)
+			}
+		}
+	}
+	for _, namespace := range slices.Sorted(maps.Keys(opts.LibraryFS)) {
+		if err := registry.LoadLibraryFS(opts.LibraryFS[namespace], ".", namespace, opts.ComponentExtensions...); err != nil {
+			if _, ok := diag.As(err); ok {
+				return nil, err
+			}
+			return nil, errors.Wrapf(err, "loading component library %s", namespace)
+		}
+	}
+
+	// Create a variables store and load from config
+	variables := vars.NewStore()
+	if config, err := vars.LoadConfig(opts.ConfigFile); err == nil {
+		variables.LoadFromConfig(config)
+	} else if !os.IsNotExist(err) {
+		// Only log if the error is not "file not found"
+		log.Printf("Warning: failed to load config file: %v", err)
+	}
+
+	siteData := data.Empty()
+	if opts.DataDir != "" {
+		tree, err := data.Load(opts.DataDir)
+		if err != nil {
+			return nil, errors.Wrap(err, "loading data")
+		}
+		siteData = tree
+	}
+
+	stored := buildcache.New()
+	if opts.CacheFile != "" {
+		stored = buildcache.Load(opts.CacheFile)
+	}
+	previous := stored
+	if opts.Force {
+		previous = buildcache.New()
+	}
+
+	// Assets are synced even when forced, since removing deleted ones relies
+	// on the list stored by the previous build
+	cache := buildcache.New()
+	result := &Result{}
+	var static *siteAssets
+	if opts.StaticDir != "" {
+		synced, err := assets.Sync(opts.StaticDir, opts.OutputDir, previousAssets(opts.OutputDir, stored), normalizeExtensions(opts.Fingerprint))
+		if err != nil {
+			return nil, err
+		}
+		if err := writeManifest(opts.OutputDir, synced.Manifest); err != nil {
+			return nil, err
+		}
+		static = &siteAssets{dir: opts.StaticDir, manifest: synced.Manifest}
+		cache.Assets = synced.Files
+		result.AssetsCopied = synced.Copied
+		result.AssetsUnchanged = synced.Unchanged
+		result.AssetsRemoved = synced.Removed
+	}
+
+	sources, err := findPages(opts.PagesDir, opts.pageExtensions())
+	if err != nil {
+		return nil, errors.Wrap(err, "walking pages directory")
+	}
+
+	jobs := make([]*pageJob, len(sources))
+	for i, source := range sources {
+		jobs[i] = &pageJob{source: source}
+	}
+
+	workers := opts.Jobs
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	// With FailFast, pages not yet started are skipped once any page fails
+	var failed atomic.Bool
+	run := func(job *pageJob, step func()) {
+		if job.err != nil || job.skipped || job.draft {
+			return
+		}
+		if opts.FailFast && failed.Load() {
+			job.skipped = true
+			return
+		}
+		step()
+		if job.err != nil {
+			failed.Store(true)
+		}
+	}
+
+	// Load every page first so output path collisions are detected before
+	// anything is written
+	parallel(len(jobs), workers, func(i int) {
+		run(jobs[i], func() { jobs[i].load(opts, registry) })
+	})
+	jobs = dropIncludes(jobs)
+	if jobs = expandPages(opts, jobs, siteData); slices.ContainsFunc(jobs, func(j *pageJob) bool { return j.err != nil }) {
+		failed.Store(true)
+	}
+	if claimOutputs(jobs, static.owners(opts.OutputDir)) {
+		failed.Store(true)
+	}
+
+	parallel(len(jobs), workers, func(i int) {
+		run(jobs[i], func() { jobs[i].generate(registry, variables, static, siteData, previous.Pages[jobs[i].outPath]) })
+	})
+
+	// Merge results in source order so the outcome never depends on scheduling
+	var failures Errors
+	for _, job := range jobs {
+		switch {
+		case job.err != nil:
+			failures = append(failures, job.err)
+			result.Failed++
+			continue
+		case job.skipped:
+			result.Skipped++
+			continue
+		case job.draft:
+			result.Drafts++
+			continue
+		case job.unchanged:
+			result.Unchanged++
+		default:
+			result.Generated++
+		}
+		cache.Pages[job.outPath] = job.entry
+	}
+
+	if opts.Sitemap {
+		if err := writeSitemap(opts, jobs, static); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(opts.Feeds) > 0 {
+		if err := writeFeeds(opts, jobs, static); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.CacheFile != "" {
+		if err := cache.Save(opts.CacheFile); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(failures) > 0 {
+		return result, failures
+	}
+	return result, nil
+}
+
+// findPages returns every page file under dir in lexical order
+func findPages(dir string, extensions []string) ([]string, error) {
+	var sources []string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !slices.Contains(extensions, filepath.Ext(path)) {
+			return err
+		}
+		sources = append(sources, path)
+		return nil
+	})
+	return sources, err
+}
+
+// parallel calls fn for every index in [0, n) using up to workers goroutines
+func parallel(n, workers int, fn func(i int)) {
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(workers, n) {
+		wg.Go(func() {
+			for i := range indexes {
+				fn(i)
+			}
+		})
+	}
+	for i := range n {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+// dropIncludes leaves out page files that another page includes, such as
+// Markdown read by <component:markdown src="...">, so they aren't also
+// published as pages of their own
+func dropIncludes(jobs []*pageJob) []*pageJob {
+	included := make(map[string]bool)
+	for _, job := range jobs {
+		if job.page == nil {
+			continue
+		}
+		for _, file := range job.page.Includes {
+			included[filepath.Clean(file)] = true
+		}
+	}
+	return slices.DeleteFunc(jobs, func(job *pageJob) bool { return included[filepath.Clean(job.source)] })
+}
+
+// claimOutputs fails every page whose output path was already claimed by a
+// static asset or an earlier page, so nothing is overwritten by a page and two
+// pages never race to write the same file. owners maps the paths already
+// claimed to their source. It reports whether any page failed.
+func claimOutputs(jobs []*pageJob, owners map[string]string) bool {
+	collided := false
+	for _, job := range jobs {
+		if job.err != nil || job.skipped || job.draft {
+			continue
+		}
+		if owner, ok := owners[job.outPath]; ok {
+			err := fmt.Errorf("page %s: output %s is already generated by %s", job.source, job.outPath, owner)
+			job.err = &PageError{Source: job.source, Kind: WriteError, Err: err}
+			collided = true
+			continue
+		}
+		owners[job.outPath] = job.source
+	}
+	return collided
+}
+
+// load parses the page and works out where it will be written
+func (j *pageJob) load(opts Options, registry *component.Registry) {
+	p, err := page.LoadPage(j.source, registry)
+	if err != nil {
+		j.err = loadError(j.source, err)
+		return
+	}
+	j.page = p
+	p.SetDefaultLayout(opts.Layout)
+	if p.Draft && !opts.Drafts {
+		j.draft = true
+		return
+	}
+
+	// Collection pages get their output paths once expanded into their items
+	if p.Collection != "" {
+		return
+	}
+	if j.outPath, err = outputPath(opts, j.source, j.page); err != nil {
+		j.err = &PageError{Source: j.source, Kind: WriteError, Err: err}
+	}
+}
+
+// generate renders and writes the page, unless its previous cache entry shows
+// that none of its inputs changed
+func (j *pageJob) generate(registry *component.Registry, variables *vars.Store, static *siteAssets, siteData *data.Tree, previous *buildcache.Entry) {
+	if previous != nil && upToDate(previous, j.source, j.outPath, j.page, registry, variables, static) {
+		j.entry = previous
+		j.unchanged = true
+		return
+	}
+
+	tracked := variables.Track()
+	usedAssets := static.track()
+	usedData := trackData(siteData)
+	for _, list := range []string{j.page.Collection, j.page.Paginate} {
+		if list != "" {
+			usedData.record(list)
+		}
+	}
+	ctx := usedData.context(usedAssets.context(&component.Context{Variables: tracked}))
+	html, err := page.GenerateContext(j.page, registry, ctx)
+	if err != nil {
+		// Render errors already point at the failing element in the page
+		if _, ok := diag.As(err); !ok {
+			err = errors.Wrap(err, "generating page "+j.source)
+		}
+		j.err = renderError(j.source, err)
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(j.outPath), 0755); err != nil {
+		j.err = &PageError{Source: j.source, Kind: WriteError, Err: errors.Wrap(err, "creating directories for "+j.outPath)}
+		return
+	}
+
+	if err := os.WriteFile(j.outPath, []byte(html), 0644); err != nil {
+		j.err = &PageError{Source: j.source, Kind: WriteError, Err: err}
+		return
+	}
+
+	if j.entry, err = recordEntry(j.source, j.page, registry, tracked, slices.Concat(j.page.Includes, usedAssets.Sources(), usedData.Sources()), usedAssets.Outputs()); err != nil {
+		j.err = &PageError{Source: j.source, Kind: WriteError, Err: err}
+	}
+}
+
+// outputPath uses the path attribute from the page, or derives one from the
+// source file location relative to the pages directory
+func outputPath(opts Options, source string, p *page.Page) (string, error) {
+	if p.Path != "" {
+		return filepath.Join(opts.OutputDir, filepath.FromSlash(p.Path)), nil
+	}
+
+	relPath, err := filepath.Rel(opts.PagesDir, source)
+	if err != nil {
+		return "", errors.Wrap(err, "getting relative path for "+source)
+	}
+
+	// Replace the page extension with .html
+	return filepath.Join(opts.OutputDir, relPath[:len(relPath)-len(filepath.Ext(relPath))]+".html"), nil
+}
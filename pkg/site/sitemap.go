@@ -0,0 +1,131 @@
+package site
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Files written by the sitemap step
+const (
+	SitemapFile = "sitemap.xml"
+	RobotsFile  = "robots.txt"
+)
+
+// sitemapNamespace is the XML namespace of the sitemaps.org protocol
+const sitemapNamespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+type urlSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// checkBaseURL makes sure the base URL is absolute, as the sitemap and feeds
+// require. feature names what needs it in the error.
+func checkBaseURL(baseURL, feature string) error {
+	if baseURL == "" {
+		return fmt.Errorf("%s requires a base URL", feature)
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("base URL %q must be an absolute URL like https://example.com", baseURL)
+	}
+	return nil
+}
+
+// writeSitemap lists every built page that didn't opt out in sitemap.xml,
+// sorted by URL, and writes robots.txt if enabled. A robots.txt in the static
+// directory takes precedence over the generated one.
+func writeSitemap(opts Options, jobs []*pageJob, static *siteAssets) error {
+	owners := static.owners(opts.OutputDir)
+	sitemapPath := filepath.Join(opts.OutputDir, SitemapFile)
+	if owner, ok := owners[sitemapPath]; ok {
+		return fmt.Errorf("%s is generated but also provided by %s", SitemapFile, owner)
+	}
+
+	set := urlSet{Xmlns: sitemapNamespace}
+	for _, job := range jobs {
+		if job.err != nil || job.skipped || job.draft || job.page.NoSitemap {
+			continue
+		}
+		entry, err := sitemapEntry(opts, job)
+		if err != nil {
+			return err
+		}
+		set.URLs = append(set.URLs, entry)
+	}
+	sort.Slice(set.URLs, func(i, j int) bool { return set.URLs[i].Loc < set.URLs[j].Loc })
+
+	if err := writeXML(sitemapPath, set); err != nil {
+		return errors.Wrap(err, "writing sitemap")
+	}
+
+	robotsPath := filepath.Join(opts.OutputDir, RobotsFile)
+	if _, ok := owners[robotsPath]; !opts.Robots || ok {
+		return nil
+	}
+	robots := fmt.Sprintf("User-agent: *\nAllow: /\n\nSitemap: %s\n", siteURL(opts.BaseURL, SitemapFile))
+	return errors.Wrap(os.WriteFile(robotsPath, []byte(robots), 0644), "writing robots.txt")
+}
+
+// sitemapEntry describes a page for the sitemap. Its lastmod comes from the
+// page's lastmod attribute, or else the modification time of its source.
+func sitemapEntry(opts Options, job *pageJob) (sitemapURL, error) {
+	rel, err := filepath.Rel(opts.OutputDir, job.outPath)
+	if err != nil {
+		return sitemapURL{}, err
+	}
+
+	lastMod := job.page.LastMod
+	if lastMod == "" {
+		info, err := os.Stat(job.source)
+		if err != nil {
+			return sitemapURL{}, err
+		}
+		lastMod = info.ModTime().UTC().Format(time.RFC3339)
+	}
+
+	return sitemapURL{Loc: siteURL(opts.BaseURL, pageURLPath(rel)), LastMod: lastMod}, nil
+}
+
+// pageURLPath turns an output path relative to the output directory into the
+// path the page is served at, dropping index.html so directories are listed
+// by their own URL
+func pageURLPath(rel string) string {
+	path := filepath.ToSlash(rel)
+	if path == "index.html" || strings.HasSuffix(path, "/index.html") {
+		return strings.TrimSuffix(path, "index.html")
+	}
+	return path
+}
+
+// siteURL joins a path relative to the site root onto the base URL
+func siteURL(baseURL, path string) string {
+	return strings.TrimSuffix(baseURL, "/") + "/" + path
+}
+
+// writeXML writes v as an indented XML document to path
+func writeXML(path string, v any) error {
+	data, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append([]byte(xml.Header), append(data, '\n')...)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -8,7 +8,58 @@ import (
 
 // Config represents the structure of .staticgen.yml
 type Config struct {
-	Variables map[string]string `yaml:"variables"`
+	Variables  map[string]string `yaml:"variables"`
+	Layout     Layout            `yaml:",inline"`
+	BaseURL    string            `yaml:"base_url"` // Absolute URL the site is published at
+	Sitemap    bool              `yaml:"sitemap"`  // Write sitemap.xml
+	Robots     bool              `yaml:"robots"`   // Write a robots.txt pointing at the sitemap
+	PageLayout string            `yaml:"layout"`   // Default layout component for pages
+	Feeds      []Feed            `yaml:"feeds"`    // RSS and Atom feeds to write
+}
+
+// Feed describes an RSS and Atom feed of dated pages. Pages are included if
+// they are under Dir, tagged Tag, or both when both are set.
+type Feed struct {
+	Title       string `yaml:"title"`
+	Description string `yaml:"description"`
+	Author      string `yaml:"author"`
+	Dir         string `yaml:"dir"`   // Directory of pages to include, relative to the pages directory
+	Tag         string `yaml:"tag"`   // Tag of pages to include
+	RSS         string `yaml:"rss"`   // Output path of the RSS feed, relative to the output directory
+	Atom        string `yaml:"atom"`  // Output path of the Atom feed, relative to the output directory
+	Limit       int    `yaml:"limit"` // Most recent pages listed; 0 lists every page
+}
+
+// Layout describes where a site's sources live and where it is generated.
+// Empty fields are left to the caller's defaults.
+type Layout struct {
+	Components          StringList            `yaml:"components"`           // Component directories
+	Libraries           map[string]StringList `yaml:"libraries"`            // Component directories by namespace
+	Pages               string                `yaml:"pages"`                // Pages directory
+	Output              string                `yaml:"output"`               // Output directory
+	Static              string                `yaml:"static"`               // Static assets directory
+	Data                string                `yaml:"data"`                 // Data files directory
+	PageExtensions      StringList            `yaml:"page_extensions"`      // Extensions of page files
+	ComponentExtensions StringList            `yaml:"component_extensions"` // Extensions of component files
+	Fingerprint         StringList            `yaml:"fingerprint"`          // Extensions of static assets to fingerprint
+}
+
+// StringList is a list of strings that may also be written as a single
+// scalar in YAML, so `components: templates` and a sequence both work
+type StringList []string
+
+// UnmarshalYAML accepts either a scalar or a sequence of scalars
+func (l *StringList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*l = StringList{node.Value}
+		return nil
+	}
+	var list []string
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
 }
 
 // LoadConfig reads the .staticgen.yml file and returns a Config
@@ -86,3 +86,54 @@ func TestStoreLoadFromConfigNil(t *testing.T) {
 	store.LoadFromConfig(&Config{Variables: nil})
 	assert.Equal(t, "value", store.GetOrDefault("existing", ""))
 }
+
+func TestLoadConfigLayout(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, ".staticgen.yml")
+
+	configContent := `components:
+  - shared/components
+  - site/components
+pages: site/pages
+output: build
+static: site/static
+page_extensions: .page
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0644))
+
+	config, err := LoadConfig(configPath)
+	require.NoError(t, err)
+
+	assert.Equal(t, Layout{
+		Components:     StringList{"shared/components", "site/components"},
+		Pages:          "site/pages",
+		Output:         "build",
+		Static:         "site/static",
+		PageExtensions: StringList{".page"},
+	}, config.Layout)
+	assert.Empty(t, config.Variables)
+}
+
+func TestLoadConfigFeeds(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, ".staticgen.yml")
+
+	configContent := `base_url: https://example.com
+feeds:
+  - title: Team Blog
+    dir: blog
+    limit: 20
+  - title: News
+    tag: news
+    rss: news/feed.xml
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0644))
+
+	config, err := LoadConfig(configPath)
+	require.NoError(t, err)
+
+	assert.Equal(t, []Feed{
+		{Title: "Team Blog", Dir: "blog", Limit: 20},
+		{Title: "News", Tag: "news", RSS: "news/feed.xml"},
+	}, config.Feeds)
+}
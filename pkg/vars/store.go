@@ -1,32 +1,92 @@
 package vars
 
-// Store holds a map of variables that can be used in page templates
+import (
+	"sort"
+	"sync"
+)
+
+// Store holds a map of variables that can be used in page templates. It is
+// safe for concurrent use, and the zero value is an empty store.
 type Store struct {
-	variables map[string]string
+	once   sync.Once
+	shared *variables // Shared with tracking views
+
+	accessMu sync.Mutex
+	accessed map[string]struct{} // Keys read through a tracking view, nil otherwise
+}
+
+// variables are the values of a store and every tracking view of it
+type variables struct {
+	mu     sync.RWMutex
+	values map[string]string
 }
 
 // NewStore creates a new Store with an empty map of variables
 func NewStore() *Store {
-	return &Store{
-		variables: make(map[string]string),
-	}
+	return &Store{}
+}
+
+// vars returns the variables of the store, creating them on first use
+func (s *Store) vars() *variables {
+	s.once.Do(func() {
+		if s.shared == nil {
+			s.shared = &variables{values: make(map[string]string)}
+		}
+	})
+	return s.shared
 }
 
 // Set sets a variable value in the store
 func (s *Store) Set(key, value string) {
-	s.variables[key] = value
+	v := s.vars()
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.values[key] = value
 }
 
 // Get retrieves a variable value from the store
 func (s *Store) Get(key string) (string, bool) {
-	value, ok := s.variables[key]
+	s.record(key)
+	v := s.vars()
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	value, ok := v.values[key]
 	return value, ok
 }
 
 // GetOrDefault retrieves a variable value from the store, or returns a default value if not found
 func (s *Store) GetOrDefault(key, defaultValue string) string {
-	if value, ok := s.variables[key]; ok {
+	if value, ok := s.Get(key); ok {
 		return value
 	}
 	return defaultValue
 }
+
+// Track returns a view of the store that shares its variables and records
+// every key read through it, so callers can tell which variables a page used
+func (s *Store) Track() *Store {
+	return &Store{
+		shared:   s.vars(),
+		accessed: make(map[string]struct{}),
+	}
+}
+
+// Accessed returns the sorted keys read through a view created by Track
+func (s *Store) Accessed() []string {
+	s.accessMu.Lock()
+	defer s.accessMu.Unlock()
+	keys := make([]string, 0, len(s.accessed))
+	for key := range s.accessed {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *Store) record(key string) {
+	if s.accessed != nil {
+		s.accessMu.Lock()
+		s.accessed[key] = struct{}{}
+		s.accessMu.Unlock()
+	}
+}
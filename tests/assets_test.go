@@ -0,0 +1,258 @@
+package tests
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blamarvt/staticgen/pkg/assets"
+	"github.com/blamarvt/staticgen/pkg/site"
+)
+
+// writeFile creates a file and its directory
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestAssetsSync(t *testing.T) {
+	src, out := t.TempDir(), t.TempDir()
+	writeFile(t, filepath.Join(src, "css", "app.css"), "body {}")
+	writeFile(t, filepath.Join(src, "img", "logo.svg"), "<svg/>")
+
+	first, err := assets.Sync(src, out, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"css/app.css", "img/logo.svg"}, first.Files)
+	assert.Equal(t, 2, first.Copied)
+	assert.FileExists(t, filepath.Join(out, "css", "app.css"))
+
+	// Nothing changed, so nothing is copied
+	second, err := assets.Sync(src, out, first.Files, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0, second.Copied)
+	assert.Equal(t, 2, second.Unchanged)
+
+	// An edited file is copied again, a deleted one is removed along with its
+	// now empty directory
+	writeFile(t, filepath.Join(src, "css", "app.css"), "body { margin: 0 }")
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(filepath.Join(src, "css", "app.css"), later, later))
+	require.NoError(t, os.Remove(filepath.Join(src, "img", "logo.svg")))
+
+	third, err := assets.Sync(src, out, second.Files, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"css/app.css"}, third.Files)
+	assert.Equal(t, 1, third.Copied)
+	assert.Equal(t, 1, third.Removed)
+
+	data, err := os.ReadFile(filepath.Join(out, "css", "app.css"))
+	require.NoError(t, err)
+	assert.Equal(t, "body { margin: 0 }", string(data))
+	assert.NoDirExists(t, filepath.Join(out, "img"))
+}
+
+func TestAssetsSyncMissingDirectory(t *testing.T) {
+	out := t.TempDir()
+	writeFile(t, filepath.Join(out, "old.js"), "")
+
+	result, err := assets.Sync(filepath.Join(t.TempDir(), "static"), out, []string{"old.js"}, nil)
+	require.NoError(t, err, "A missing static directory should be treated as empty")
+	assert.Empty(t, result.Files)
+	assert.Equal(t, 1, result.Removed)
+	assert.NoFileExists(t, filepath.Join(out, "old.js"))
+}
+
+func TestSiteBuildCopiesStaticAssets(t *testing.T) {
+	dir := copyFixtureSite(t)
+	writeFile(t, filepath.Join(dir, "static", "app.css"), "body {}")
+	writeFile(t, filepath.Join(dir, "static", "fonts", "inter.woff2"), "font")
+
+	opts := site.Options{
+		ComponentsDir: filepath.Join(dir, "components"),
+		PagesDir:      filepath.Join(dir, "pages"),
+		OutputDir:     filepath.Join(dir, "dist"),
+		StaticDir:     filepath.Join(dir, "static"),
+		CacheFile:     filepath.Join(dir, ".staticgen-cache.json"),
+	}
+	result, err := site.Build(opts)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.AssetsCopied)
+	assert.FileExists(t, filepath.Join(dir, "dist", "fonts", "inter.woff2"))
+
+	// The list of copied assets survives in the cache, even for forced builds
+	require.NoError(t, os.RemoveAll(filepath.Join(dir, "static", "fonts")))
+	opts.Force = true
+	result, err = site.Build(opts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.AssetsUnchanged)
+	assert.Equal(t, 1, result.AssetsRemoved)
+	assert.NoFileExists(t, filepath.Join(dir, "dist", "fonts", "inter.woff2"))
+	assert.FileExists(t, filepath.Join(dir, "dist", "test.html"), "Generated pages must not be removed")
+}
+
+func TestSiteBuildRemovesDeletedAssetsWithoutCache(t *testing.T) {
+	dir := copyFixtureSite(t)
+	writeFile(t, filepath.Join(dir, "static", "app.css"), "body {}")
+	writeFile(t, filepath.Join(dir, "static", "fonts", "inter.woff2"), "font")
+
+	opts := site.Options{
+		ComponentsDir: filepath.Join(dir, "components"),
+		PagesDir:      filepath.Join(dir, "pages"),
+		OutputDir:     filepath.Join(dir, "dist"),
+		StaticDir:     filepath.Join(dir, "static"),
+	}
+	_, err := site.Build(opts)
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(dir, "dist", "fonts", "inter.woff2"))
+
+	// The manifest in the output directory lists what the last build copied
+	require.NoError(t, os.RemoveAll(filepath.Join(dir, "static", "fonts")))
+	result, err := site.Build(opts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.AssetsRemoved)
+	assert.NoFileExists(t, filepath.Join(dir, "dist", "fonts", "inter.woff2"))
+	assert.FileExists(t, filepath.Join(dir, "dist", "app.css"))
+
+	// Removing the last asset removes the manifest too
+	require.NoError(t, os.Remove(filepath.Join(dir, "static", "app.css")))
+	result, err = site.Build(opts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.AssetsRemoved)
+	assert.NoFileExists(t, filepath.Join(dir, "dist", "app.css"))
+	assert.NoFileExists(t, filepath.Join(dir, "dist", "asset-manifest.json"))
+}
+
+func TestSiteBuildPageCollidesWithAsset(t *testing.T) {
+	dir := copyFixtureSite(t)
+	writeFile(t, filepath.Join(dir, "static", "test.html"), "<p>static</p>")
+
+	_, err := site.Build(site.Options{
+		ComponentsDir: filepath.Join(dir, "components"),
+		PagesDir:      filepath.Join(dir, "pages"),
+		OutputDir:     filepath.Join(dir, "dist"),
+		StaticDir:     filepath.Join(dir, "static"),
+	})
+	var failures site.Errors
+	require.ErrorAs(t, err, &failures)
+	require.Len(t, failures, 1)
+	assert.Equal(t, site.WriteError, failures[0].Kind)
+	assert.Contains(t, err.Error(), filepath.Join(dir, "static", "test.html"))
+
+	data, err := os.ReadFile(filepath.Join(dir, "dist", "test.html"))
+	require.NoError(t, err)
+	assert.Equal(t, "<p>static</p>", string(data), "The asset should not be overwritten")
+}
+
+// copyAssetSite copies the asset fixture site into a temporary directory
+func copyAssetSite(t *testing.T) (string, site.Options) {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.CopyFS(dir, os.DirFS("fixtures/assets")))
+	return dir, site.Options{
+		ComponentsDir: filepath.Join(dir, "components"),
+		PagesDir:      filepath.Join(dir, "pages"),
+		OutputDir:     filepath.Join(dir, "dist"),
+		StaticDir:     filepath.Join(dir, "static"),
+		CacheFile:     filepath.Join(dir, ".staticgen-cache.json"),
+		Fingerprint:   []string{".css", "js"},
+	}
+}
+
+func TestAssetFingerprint(t *testing.T) {
+	assert.Equal(t, "css/app.3f9a1c2b.css", assets.Fingerprint("css/app.css", "3f9a1c2b5e"))
+	assert.Equal(t, "LICENSE.3f9a1c2b", assets.Fingerprint("LICENSE", "3f9a1c2b5e"))
+}
+
+func TestSiteBuildFingerprintsAssets(t *testing.T) {
+	dir, opts := copyAssetSite(t)
+
+	_, err := site.Build(opts)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(filepath.Join(dir, "dist", assets.ManifestFile))
+	require.NoError(t, err)
+	var manifest assets.Manifest
+	require.NoError(t, json.Unmarshal(data, &manifest))
+	assert.Equal(t, "robots.txt", manifest["robots.txt"], "Only listed extensions are fingerprinted")
+	assert.Regexp(t, `^css/app\.[0-9a-f]{8}\.css$`, manifest["css/app.css"])
+
+	assert.FileExists(t, filepath.Join(dir, "dist", filepath.FromSlash(manifest["css/app.css"])))
+	assert.NoFileExists(t, filepath.Join(dir, "dist", "css", "app.css"))
+
+	html, err := os.ReadFile(filepath.Join(dir, "dist", "index.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(html), `href="/`+manifest["css/app.css"]+`"`)
+}
+
+func TestSiteBuildRegeneratesPagesWhenAssetsChange(t *testing.T) {
+	dir, opts := copyAssetSite(t)
+
+	_, err := site.Build(opts)
+	require.NoError(t, err)
+
+	writeFile(t, filepath.Join(dir, "static", "css", "app.css"), "body { margin: 1em; }")
+	result, err := site.Build(opts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Generated, "Only the page linking to the stylesheet should be regenerated")
+	assert.Equal(t, 1, result.Unchanged)
+	assert.Equal(t, 1, result.AssetsRemoved, "The old fingerprinted file should be removed")
+
+	data, err := os.ReadFile(filepath.Join(dir, "dist", assets.ManifestFile))
+	require.NoError(t, err)
+	var manifest assets.Manifest
+	require.NoError(t, json.Unmarshal(data, &manifest))
+
+	html, err := os.ReadFile(filepath.Join(dir, "dist", "index.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(html), manifest["css/app.css"])
+}
+
+func TestSiteBuildRegeneratesPagesWhenFingerprintingChanges(t *testing.T) {
+	dir, opts := copyAssetSite(t)
+	opts.Fingerprint = nil
+
+	_, err := site.Build(opts)
+	require.NoError(t, err)
+
+	// Turning fingerprinting on moves the stylesheet, so the page linking to it
+	// must be regenerated even though no file changed
+	opts.Fingerprint = []string{".css"}
+	result, err := site.Build(opts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Generated)
+	assert.Equal(t, 1, result.AssetsRemoved)
+
+	data, err := os.ReadFile(filepath.Join(dir, "dist", assets.ManifestFile))
+	require.NoError(t, err)
+	var manifest assets.Manifest
+	require.NoError(t, json.Unmarshal(data, &manifest))
+	html, err := os.ReadFile(filepath.Join(dir, "dist", "index.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(html), `href="/`+manifest["css/app.css"]+`"`)
+
+	// And turning it off again links the plain file
+	opts.Fingerprint = nil
+	result, err = site.Build(opts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Generated)
+	html, err = os.ReadFile(filepath.Join(dir, "dist", "index.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(html), `href="/css/app.css"`)
+}
+
+func TestAssetFunctionUnknownAsset(t *testing.T) {
+	_, opts := copyAssetSite(t)
+	require.NoError(t, os.Remove(filepath.Join(filepath.Dir(opts.StaticDir), "static", "css", "app.css")))
+
+	_, err := site.Build(opts)
+	var failures site.Errors
+	require.ErrorAs(t, err, &failures)
+	assert.Equal(t, site.TemplateError, failures[0].Kind)
+	assert.Contains(t, err.Error(), `unknown static asset "css/app.css"`)
+}
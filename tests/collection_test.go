@@ -0,0 +1,141 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blamarvt/staticgen/pkg/component"
+	"github.com/blamarvt/staticgen/pkg/page"
+	"github.com/blamarvt/staticgen/pkg/site"
+)
+
+func TestPageForItem(t *testing.T) {
+	registry := component.NewRegistry()
+	require.NoError(t, registry.LoadAll("fixtures/collections/components"))
+
+	p, err := page.LoadPage("fixtures/collections/pages/product.hcml", registry)
+	require.NoError(t, err)
+	assert.Equal(t, "products", p.Collection)
+	assert.Empty(t, p.Params, "collection is not a custom parameter")
+
+	item, err := p.ForItem(map[string]any{"slug": "widget", "name": "Widget"})
+	require.NoError(t, err)
+	assert.Equal(t, "/products/widget.html", item.Path)
+	assert.Equal(t, "Widget | Shop", item.Title)
+	assert.Equal(t, "/products/{slug}.html", p.Path, "The collection page itself is unchanged")
+
+	_, err = p.ForItem(map[string]any{"name": "Nameless"})
+	assert.EqualError(t, err, `fixtures/collections/pages/product.hcml:1:1: collection item has no field "slug" for placeholder {slug}`)
+}
+
+func TestSiteBuildCollectionPathTraversal(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "data", "products.yml"), "- slug: ../../escaped\n  name: Escaped\n  price: 1\n")
+
+	_, err := site.Build(site.Options{
+		ComponentsDir: "fixtures/collections/components",
+		PagesDir:      "fixtures/collections/pages",
+		OutputDir:     filepath.Join(dir, "out", "dist"),
+		DataDir:       filepath.Join(dir, "data"),
+	})
+	var errs site.Errors
+	require.ErrorAs(t, err, &errs)
+	assert.Equal(t, site.DataError, errs[0].Kind)
+	assert.Contains(t, err.Error(), `collection item field "slug" is "../../escaped", which can't be used in a path`)
+	assert.NoFileExists(t, filepath.Join(dir, "escaped.html"))
+	assert.NoFileExists(t, filepath.Join(dir, "out", "escaped.html"))
+
+	p, err := page.LoadPage("fixtures/collections/pages/product.hcml", component.NewRegistry())
+	require.NoError(t, err)
+	for _, slug := range []string{"a/b", `a\b`, ".."} {
+		_, err := p.ForItem(map[string]any{"slug": slug, "name": "Name"})
+		assert.ErrorContains(t, err, "can't be used in a path", slug)
+	}
+	_, err = p.ForItem(map[string]any{"slug": "ok", "name": "A/B testing"})
+	assert.NoError(t, err, "Only fields in the path are restricted")
+
+	// The output path is checked too, whatever the page's pattern
+	writeFile(t, filepath.Join(dir, "data", "products.yml"), "- slug: widget\n")
+	writeFile(t, filepath.Join(dir, "pages", "product.hcml"), `<page hcmlns="staticgen" collection="products" path="/../{slug}.html"></page>`)
+	_, err = site.Build(site.Options{
+		PagesDir:  filepath.Join(dir, "pages"),
+		OutputDir: filepath.Join(dir, "out", "dist"),
+		DataDir:   filepath.Join(dir, "data"),
+	})
+	assert.ErrorContains(t, err, "collection item path /../widget.html is outside the output directory")
+	assert.NoFileExists(t, filepath.Join(dir, "out", "widget.html"))
+}
+
+func TestCollectionNeedsPathPlaceholder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.hcml")
+	writeFile(t, path, `<page hcmlns="staticgen" collection="products" path="/products.html"></page>`)
+
+	_, err := page.LoadPage(path, component.NewRegistry())
+	assert.ErrorContains(t, err, "collection page needs a path with placeholders")
+}
+
+func TestSiteBuildCollection(t *testing.T) {
+	dir := t.TempDir()
+	dataDir := filepath.Join(dir, "data")
+	writeFile(t, filepath.Join(dataDir, "products.yml"), "- slug: widget\n  name: Widget\n  price: 5\n- slug: gadget\n  name: Gadget\n  price: 20\n")
+
+	opts := site.Options{
+		ComponentsDir: "fixtures/collections/components",
+		PagesDir:      "fixtures/collections/pages",
+		OutputDir:     filepath.Join(dir, "dist"),
+		DataDir:       dataDir,
+		CacheFile:     filepath.Join(dir, "cache.json"),
+	}
+	result, err := site.Build(opts)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Generated, "One page per item")
+
+	html, err := os.ReadFile(filepath.Join(dir, "dist", "products", "gadget.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(html), "<title>Gadget | Shop</title>")
+	assert.Contains(t, string(html), "<h1>Gadget</h1>")
+	assert.Contains(t, string(html), "Price: $20")
+	assert.FileExists(t, filepath.Join(dir, "dist", "products", "widget.html"))
+
+	result, err = site.Build(opts)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Unchanged)
+
+	// Editing the data file rebuilds the collection
+	time.Sleep(10 * time.Millisecond)
+	writeFile(t, filepath.Join(dataDir, "products.yml"), "- slug: widget\n  name: Widget\n  price: 7\n- slug: gadget\n  name: Gadget\n  price: 20\n")
+	result, err = site.Build(opts)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Generated)
+
+	html, err = os.ReadFile(filepath.Join(dir, "dist", "products", "widget.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(html), "Price: $7")
+}
+
+func TestSiteBuildCollectionErrors(t *testing.T) {
+	dir := t.TempDir()
+	opts := site.Options{
+		ComponentsDir: "fixtures/collections/components",
+		PagesDir:      "fixtures/collections/pages",
+		OutputDir:     filepath.Join(dir, "dist"),
+		DataDir:       filepath.Join(dir, "data"),
+	}
+
+	_, err := site.Build(opts)
+	var errs site.Errors
+	require.ErrorAs(t, err, &errs)
+	assert.Equal(t, site.DataError, errs[0].Kind)
+	assert.Contains(t, err.Error(), `collection: no data at "products"`)
+
+	writeFile(t, filepath.Join(dir, "data", "products.yml"), "slug: widget\n")
+	_, err = site.Build(opts)
+	require.ErrorAs(t, err, &errs)
+	assert.Contains(t, err.Error(), `collection "products" is not a list`)
+	assert.Equal(t, "1 error (1 data)", errs.Summary())
+}
@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blamarvt/staticgen/pkg/data"
+	"github.com/blamarvt/staticgen/pkg/site"
+)
+
+func TestDataLoad(t *testing.T) {
+	tree, err := data.Load("fixtures/data/data")
+	require.NoError(t, err)
+
+	name, err := tree.Lookup("team.name")
+	require.NoError(t, err)
+	assert.Equal(t, "Staticgen", name)
+
+	role, err := tree.Lookup("team.members.1.role")
+	require.NoError(t, err)
+	assert.Equal(t, "Admiral", role, "List elements are addressed by index")
+
+	nav, err := tree.Lookup("nav.main")
+	require.NoError(t, err)
+	assert.Len(t, nav, 2, "Subdirectories become nested keys")
+
+	price, err := tree.Lookup("prices.0.price")
+	require.NoError(t, err)
+	assert.Equal(t, "5", price, "CSV rows are keyed by the header row")
+
+	_, err = tree.Lookup("team.missing")
+	assert.EqualError(t, err, `no data at "team.missing"`)
+
+	assert.Equal(t, []string{filepath.Join("fixtures/data/data", "team.yml")}, tree.Files("team.members"))
+	assert.Equal(t, []string{filepath.Join("fixtures/data/data", "nav", "main.json")}, tree.Files("nav"))
+}
+
+func TestDataLoadMissingDir(t *testing.T) {
+	tree, err := data.Load(filepath.Join(t.TempDir(), "data"))
+	require.NoError(t, err)
+	_, err = tree.Lookup("team")
+	assert.Error(t, err)
+}
+
+func TestDataLoadConflict(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "team.yml"), "name: A\n")
+	writeFile(t, filepath.Join(dir, "team.json"), `{"name": "B"}`)
+
+	_, err := data.Load(dir)
+	assert.ErrorContains(t, err, "conflicts with")
+}
+
+func TestSiteBuildData(t *testing.T) {
+	dir := t.TempDir()
+	cacheFile := filepath.Join(dir, "cache.json")
+	dataDir := filepath.Join(dir, "data")
+	writeFile(t, filepath.Join(dataDir, "team.yml"), "name: Staticgen\nmembers:\n  - name: Ada\n    role: Engineer\n")
+	writeFile(t, filepath.Join(dataDir, "nav", "main.json"), `[{"title": "Home", "url": "/"}]`)
+	writeFile(t, filepath.Join(dataDir, "prices.csv"), "plan,price\nBasic,5\nPro,20\n")
+
+	opts := site.Options{
+		ComponentsDir: "fixtures/data/components",
+		PagesDir:      "fixtures/data/pages",
+		OutputDir:     filepath.Join(dir, "dist"),
+		DataDir:       dataDir,
+		CacheFile:     cacheFile,
+	}
+	_, err := site.Build(opts)
+	require.NoError(t, err)
+
+	html, err := os.ReadFile(filepath.Join(dir, "dist", "index.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(html), `<a href="/">Home</a>`)
+	assert.Contains(t, string(html), "<h1>Staticgen</h1>")
+	assert.Contains(t, string(html), "<li>Ada (Engineer)")
+	assert.Contains(t, string(html), "Pro costs $20")
+
+	result, err := site.Build(opts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Unchanged)
+
+	// Changing a data file the page read rebuilds it
+	time.Sleep(10 * time.Millisecond)
+	writeFile(t, filepath.Join(dataDir, "team.yml"), "name: Staticgen\nmembers:\n  - name: Grace\n    role: Admiral\n")
+	result, err = site.Build(opts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Generated)
+
+	html, err = os.ReadFile(filepath.Join(dir, "dist", "index.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(html), "<li>Grace (Admiral)")
+}
+
+func TestSiteBuildMissingData(t *testing.T) {
+	dir := t.TempDir()
+	_, err := site.Build(site.Options{
+		ComponentsDir: "fixtures/data/components",
+		PagesDir:      "fixtures/data/pages",
+		OutputDir:     filepath.Join(dir, "dist"),
+		DataDir:       filepath.Join(dir, "data"),
+	})
+	var errs site.Errors
+	require.ErrorAs(t, err, &errs)
+	assert.Contains(t, err.Error(), `no data at "nav.main"`)
+}
@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blamarvt/staticgen/pkg/devserver"
+)
+
+func TestDevServerInjectsReloadScript(t *testing.T) {
+	server := devserver.New(func(outputDir string) error {
+		return os.WriteFile(filepath.Join(outputDir, "index.html"), []byte("<html><body><p>Hi</p></body></html>"), 0644)
+	})
+	defer server.Close()
+	require.NoError(t, server.Rebuild())
+
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), devserver.ReloadPath)
+	assert.True(t, strings.HasSuffix(string(body), "</script></body></html>"), "Script should be injected before </body>")
+}
+
+func TestDevServerNotifiesOnRebuild(t *testing.T) {
+	server := devserver.New(func(outputDir string) error {
+		return os.WriteFile(filepath.Join(outputDir, "index.html"), []byte("<html><body></body></html>"), 0644)
+	})
+	defer server.Close()
+	require.NoError(t, server.Rebuild())
+
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + devserver.ReloadPath)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	events := make(chan string, 1)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if strings.HasPrefix(scanner.Text(), "event:") {
+				events <- scanner.Text()
+				return
+			}
+		}
+	}()
+
+	// The client registers when the stream opens, so retry until it hears us
+	deadline := time.After(5 * time.Second)
+	for {
+		require.NoError(t, server.Rebuild())
+		select {
+		case event := <-events:
+			assert.Equal(t, "event: reload", event)
+			return
+		case <-time.After(50 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("Timed out waiting for reload event")
+		}
+	}
+}
+
+func TestDevServerWatchesPathsSetByBuild(t *testing.T) {
+	initial, moved := t.TempDir(), t.TempDir()
+
+	// Like a config change naming another directory, every build watches moved
+	var builds atomic.Int32
+	var server *devserver.Server
+	server = devserver.New(func(outputDir string) error {
+		builds.Add(1)
+		server.SetWatch(moved)
+		return nil
+	}, initial)
+	defer server.Close()
+	require.NoError(t, server.Rebuild())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go server.Watch(ctx)
+
+	// The watcher snapshots the paths when it starts, so keep changing the file
+	// until it notices
+	content := "<page/>"
+	assert.Eventually(t, func() bool {
+		content += "\n"
+		require.NoError(t, os.WriteFile(filepath.Join(moved, "page.hcml"), []byte(content), 0644))
+		return builds.Load() >= 2
+	}, 5*time.Second, 100*time.Millisecond, "A change in the newly watched directory should trigger a rebuild")
+}
@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blamarvt/staticgen/pkg/component"
+	"github.com/blamarvt/staticgen/pkg/diag"
+	"github.com/blamarvt/staticgen/pkg/page"
+	"github.com/blamarvt/staticgen/pkg/vars"
+)
+
+func TestParseErrorPosition(t *testing.T) {
+	registry := component.NewRegistry()
+
+	_, err := page.LoadPage("fixtures/errors/malformed.hcml", registry)
+	require.Error(t, err)
+
+	diagErr, ok := diag.As(err)
+	require.True(t, ok, "Expected a positioned error, got %v", err)
+	assert.Equal(t, "fixtures/errors/malformed.hcml", diagErr.Pos.File)
+	assert.Equal(t, 6, diagErr.Pos.Line)
+	assert.Equal(t, 9, diagErr.Pos.Col, "The column is where the decoder stopped, after </div>")
+	assert.Contains(t, err.Error(), "fixtures/errors/malformed.hcml:6:9: failed to parse XML")
+}
+
+func TestValidationErrorPosition(t *testing.T) {
+	registry := component.NewRegistry()
+	require.NoError(t, registry.LoadAll("fixtures/components"))
+
+	_, err := page.LoadPage("fixtures/invalid/unknown-attr.hcml", registry)
+	require.Error(t, err)
+
+	diagErr, ok := diag.As(err)
+	require.True(t, ok, "Expected a positioned error, got %v", err)
+	assert.Equal(t, diag.Position{File: "fixtures/invalid/unknown-attr.hcml", Line: 6, Col: 5}, diagErr.Pos)
+}
+
+func TestTemplateParseErrorPosition(t *testing.T) {
+	registry := component.NewRegistry()
+
+	err := registry.LoadAll("fixtures/broken")
+	require.Error(t, err)
+
+	diagErr, ok := diag.As(err)
+	require.True(t, ok, "Expected a positioned error, got %v", err)
+	assert.Equal(t, "fixtures/broken/broken.hcml", diagErr.Pos.File)
+	assert.Equal(t, 4, diagErr.Pos.Line)
+}
+
+func TestRenderErrorPositionAndChain(t *testing.T) {
+	registry := component.NewRegistry()
+	require.NoError(t, registry.LoadAll("fixtures/components"))
+	require.NoError(t, registry.LoadAll("fixtures/errors/components"))
+
+	p, err := page.LoadPage("fixtures/errors/faulty.hcml", registry)
+	require.NoError(t, err, "Failed to load page")
+
+	_, err = page.Generate(p, registry, vars.NewStore())
+	require.Error(t, err)
+
+	diagErr, ok := diag.As(err)
+	require.True(t, ok, "Expected a positioned error, got %v", err)
+
+	// The page element that used the component
+	assert.Equal(t, diag.Position{File: "fixtures/errors/faulty.hcml", Line: 7, Col: 7}, diagErr.Pos)
+	// The template expression that failed, in the component definition file
+	assert.Equal(t, diag.Position{File: "fixtures/errors/components/faulty.hcml", Line: 5, Col: 24}, diagErr.TemplatePos)
+	assert.Equal(t, []string{"skeleton", "container", "faulty"}, diagErr.Chain)
+
+	assert.Contains(t, err.Error(), "fixtures/errors/faulty.hcml:7:7: failed to execute template for faulty")
+	assert.Contains(t, err.Error(), "fixtures/errors/components/faulty.hcml:5:24: in component template")
+	assert.Contains(t, err.Error(), "while rendering skeleton > container > faulty")
+}
+
+func TestMissingComponentPositionAndChain(t *testing.T) {
+	registry := component.NewRegistry()
+	require.NoError(t, registry.LoadAll("fixtures/components"))
+
+	// The faulty component is not registered
+	p, err := page.LoadPage("fixtures/errors/faulty.hcml", registry)
+	require.NoError(t, err, "Failed to load page")
+
+	_, err = page.Generate(p, registry, vars.NewStore())
+	require.Error(t, err)
+
+	diagErr, ok := diag.As(err)
+	require.True(t, ok, "Expected a positioned error, got %v", err)
+	assert.Equal(t, 7, diagErr.Pos.Line)
+	assert.Equal(t, []string{"skeleton", "container", "faulty"}, diagErr.Chain)
+	assert.Contains(t, err.Error(), "component definition not found: faulty")
+}
@@ -178,6 +178,9 @@ func TestEndToEndAllFixtures(t *testing.T) {
 		"fixtures/pages/simple.hcml",
 		"fixtures/pages/nested.hcml",
 		"fixtures/pages/mixed.hcml",
+		"fixtures/pages/defaults.hcml",
+		"fixtures/pages/slots.hcml",
+		"fixtures/pages/panels.hcml",
 	}
 
 	for _, pagePath := range pages {
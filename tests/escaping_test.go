@@ -0,0 +1,62 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blamarvt/staticgen/pkg/component"
+	"github.com/blamarvt/staticgen/pkg/vars"
+)
+
+func TestRenderEscapesAttributesAndVariables(t *testing.T) {
+	registry := component.NewRegistry()
+	err := registry.Register(&component.Definition{
+		Name:     "note",
+		Template: `<p title="{{ .Title }}">{{ .Message }}</p><span>{{ Var "motto" }}</span>{{ .Children }}{{ Slot "extra" }}`,
+	})
+	require.NoError(t, err)
+
+	variables := vars.NewStore()
+	variables.Set("motto", "<b>bold</b> & brave")
+
+	inst := &component.Instance{
+		DefinitionName: "note",
+		Attributes: map[string]string{
+			"title":   `say "hi"`,
+			"message": "<script>alert(1)</script>",
+		},
+		Children: []*component.Instance{{RawHTML: "<em>trusted child</em>"}},
+		Slots: map[string][]*component.Instance{
+			"extra": {{RawHTML: "<aside>trusted slot</aside>"}},
+		},
+	}
+
+	html, err := inst.Render(registry, variables)
+	require.NoError(t, err)
+
+	assert.Contains(t, html, `title="say &#34;hi&#34;"`, "Attribute values are escaped for attribute context")
+	assert.Contains(t, html, "&lt;script&gt;alert(1)&lt;/script&gt;", "Attribute values are escaped for text context")
+	assert.NotContains(t, html, "<script>")
+	assert.Contains(t, html, "&lt;b&gt;bold&lt;/b&gt; &amp; brave", "Variables are escaped")
+	assert.Contains(t, html, "<em>trusted child</em>", "Children are trusted markup")
+	assert.Contains(t, html, "<aside>trusted slot</aside>", "Slot content is trusted markup")
+}
+
+func TestRenderSafeMarksTrustedMarkup(t *testing.T) {
+	registry := component.NewRegistry()
+	err := registry.Register(&component.Definition{
+		Name:     "banner",
+		Template: `<div>{{ Safe (Var "banner") }}</div>`,
+	})
+	require.NoError(t, err)
+
+	variables := vars.NewStore()
+	variables.Set("banner", "<strong>Sale!</strong>")
+
+	inst := &component.Instance{DefinitionName: "banner"}
+	html, err := inst.Render(registry, variables)
+	require.NoError(t, err)
+	assert.Equal(t, "<div><strong>Sale!</strong></div>", html)
+}
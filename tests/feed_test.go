@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blamarvt/staticgen/pkg/site"
+	"github.com/blamarvt/staticgen/pkg/vars"
+)
+
+// writeBlog creates a site with dated blog posts and an undated about page
+func writeBlog(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "pages", "blog", "first.hcml"), `<page hcmlns="staticgen" title="First Post" date="2026-01-05" description="Where it began"><p>1</p></page>`)
+	writeFile(t, filepath.Join(dir, "pages", "blog", "second.hcml"), `<page hcmlns="staticgen" title="Second &amp; Last" date="2026-02-10" tags="news"><p>2</p></page>`)
+	writeFile(t, filepath.Join(dir, "pages", "blog", "draft.hcml"), `<page hcmlns="staticgen" title="Draft" date="2026-03-01" draft="true"><p>3</p></page>`)
+	writeFile(t, filepath.Join(dir, "pages", "blog", "index.hcml"), `<page hcmlns="staticgen" title="Blog"><p>Posts</p></page>`)
+	writeFile(t, filepath.Join(dir, "pages", "launch.hcml"), `<page hcmlns="staticgen" title="Launch" date="2026-02-10" tags="news"><p>Launch</p></page>`)
+	return dir
+}
+
+func TestSiteBuildFeeds(t *testing.T) {
+	dir := writeBlog(t)
+	opts := site.Options{
+		PagesDir:  filepath.Join(dir, "pages"),
+		OutputDir: filepath.Join(dir, "dist"),
+		BaseURL:   "https://example.com",
+		Feeds:     []vars.Feed{{Title: "Team Blog", Author: "The Team", Dir: "blog"}},
+	}
+	_, err := site.Build(opts)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(filepath.Join(dir, "dist", "feed.xml"))
+	require.NoError(t, err)
+	rss := string(data)
+	assert.Contains(t, rss, `<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom">`)
+	assert.Contains(t, rss, `<atom:link href="https://example.com/feed.xml" rel="self" type="application/rss+xml"></atom:link>`)
+	assert.Contains(t, rss, "<title>Team Blog</title>")
+	assert.Contains(t, rss, "<link>https://example.com/</link>")
+	assert.Contains(t, rss, "<lastBuildDate>Tue, 10 Feb 2026 00:00:00 +0000</lastBuildDate>", "The feed is as new as its newest page")
+	assert.Contains(t, rss, "<title>Second &amp; Last</title>")
+	assert.Contains(t, rss, "<link>https://example.com/blog/first.html</link>")
+	assert.Contains(t, rss, "<pubDate>Mon, 05 Jan 2026 00:00:00 +0000</pubDate>")
+	assert.Contains(t, rss, "<description>Where it began</description>")
+	assert.NotContains(t, rss, "Draft")
+	assert.NotContains(t, rss, "Launch", "Only pages under the feed's directory are listed")
+	assert.NotContains(t, rss, "<title>Blog</title>", "Undated pages are left out")
+	assert.Less(t, strings.Index(rss, "Second"), strings.Index(rss, "First Post"), "Newest pages come first")
+
+	data, err = os.ReadFile(filepath.Join(dir, "dist", "atom.xml"))
+	require.NoError(t, err)
+	atom := string(data)
+	assert.Contains(t, atom, `<feed xmlns="http://www.w3.org/2005/Atom">`)
+	assert.Contains(t, atom, `<link href="https://example.com/atom.xml" rel="self"></link>`)
+	assert.Contains(t, atom, "<updated>2026-02-10T00:00:00Z</updated>")
+	assert.Contains(t, atom, "<name>The Team</name>")
+	assert.Contains(t, atom, "<id>https://example.com/blog/second.html</id>")
+	assert.Contains(t, atom, "<summary>Where it began</summary>")
+
+	// The same pages always give the same feed
+	_, err = site.Build(opts)
+	require.NoError(t, err)
+	again, err := os.ReadFile(filepath.Join(dir, "dist", "atom.xml"))
+	require.NoError(t, err)
+	assert.Equal(t, atom, string(again))
+}
+
+func TestSiteBuildTagFeed(t *testing.T) {
+	dir := writeBlog(t)
+	_, err := site.Build(site.Options{
+		PagesDir:  filepath.Join(dir, "pages"),
+		OutputDir: filepath.Join(dir, "dist"),
+		BaseURL:   "https://example.com",
+		Feeds:     []vars.Feed{{Title: "News", Tag: "news", RSS: "news/feed.xml", Limit: 1}},
+	})
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(filepath.Join(dir, "dist", "news", "feed.xml"))
+	require.NoError(t, err)
+	rss := string(data)
+	assert.Contains(t, rss, "<link>https://example.com/blog/second.html</link>", "Pages from the same date are ordered by URL")
+	assert.NotContains(t, rss, "launch.html", "The limit keeps only the first pages")
+	assert.NoFileExists(t, filepath.Join(dir, "dist", "atom.xml"), "Only the configured formats are written")
+}
+
+func TestSiteBuildAtomFeedWithoutAuthor(t *testing.T) {
+	dir := writeBlog(t)
+	_, err := site.Build(site.Options{
+		PagesDir:  filepath.Join(dir, "pages"),
+		OutputDir: filepath.Join(dir, "dist"),
+		BaseURL:   "https://example.com",
+		Feeds:     []vars.Feed{{Title: "News", Tag: "news", Atom: "news/atom.xml"}},
+	})
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(filepath.Join(dir, "dist", "news", "atom.xml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "<author>\n    <name>News</name>\n  </author>", "Atom feeds need an author, so the title stands in")
+}
+
+func TestSiteBuildFeedErrors(t *testing.T) {
+	dir := writeBlog(t)
+	opts := site.Options{
+		PagesDir:  filepath.Join(dir, "pages"),
+		OutputDir: filepath.Join(dir, "dist"),
+		Feeds:     []vars.Feed{{Title: "Blog", Dir: "blog", RSS: "blog/first.html"}},
+	}
+	_, err := site.Build(opts)
+	assert.EqualError(t, err, "feeds requires a base URL")
+
+	opts.BaseURL = "https://example.com"
+	_, err = site.Build(opts)
+	assert.ErrorContains(t, err, "feed blog/first.html is generated but also provided by "+filepath.Join(dir, "pages", "blog", "first.hcml"))
+}
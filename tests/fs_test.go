@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"embed"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blamarvt/staticgen/pkg/component"
+	"github.com/blamarvt/staticgen/pkg/diag"
+	"github.com/blamarvt/staticgen/pkg/page"
+	"github.com/blamarvt/staticgen/pkg/site"
+)
+
+//go:embed fixtures/components
+var embeddedComponents embed.FS
+
+// designSystem is a component library as a Go package might ship it
+var designSystem = fstest.MapFS{
+	"button.hcml": {Data: []byte(`<button hcmlns="staticgen:components">
+    <attribute name="label" required="true" />
+    <button class="ds-button">{{ .Label }}</button>
+</button>`)},
+	"forms/field.hcml": {Data: []byte(`<field hcmlns="staticgen:components">
+    <label>{{ .Children }}</label>
+</field>`)},
+	"README.md": {Data: []byte("Not a component")},
+}
+
+func TestRegistryLoadAllFromEmbed(t *testing.T) {
+	registry := component.NewRegistry()
+	require.NoError(t, registry.LoadAllFS(embeddedComponents, "fixtures/components"))
+
+	greeting := registry.Get("greeting")
+	require.NotNil(t, greeting)
+	assert.Equal(t, "fixtures/components/greeting.hcml", greeting.Path)
+
+	p, err := page.LoadPage("fixtures/pages/simple.hcml", registry)
+	require.NoError(t, err)
+	_, err = page.Generate(p, registry, nil)
+	require.NoError(t, err)
+}
+
+func TestRegistryLoadLibraryFS(t *testing.T) {
+	registry := component.NewRegistry()
+	require.NoError(t, registry.LoadLibraryFS(designSystem, ".", "ds"))
+
+	require.NotNil(t, registry.Get("ds:button"))
+	require.NotNil(t, registry.Get("ds:field"), "Subdirectories should be loaded")
+	assert.Equal(t, "forms/field.hcml", registry.Get("ds:field").Path)
+
+	pages := fstest.MapFS{
+		"index.hcml": {Data: []byte(`<page hcmlns="staticgen" title="Form">
+  <ds:field><ds:button label="Send" /></ds:field>
+</page>`)},
+	}
+	p, err := page.LoadPageFS(pages, "index.hcml", registry)
+	require.NoError(t, err)
+
+	html, err := page.Generate(p, registry, nil)
+	require.NoError(t, err)
+	assert.Contains(t, html, `class="ds-button"`)
+}
+
+func TestLoadFromFSReportsPositions(t *testing.T) {
+	fsys := fstest.MapFS{
+		"broken/card.hcml": {Data: []byte("<card hcmlns=\"staticgen:components\">\n  <p>{{ if .X }}</p>\n</card>")},
+		"pages/bad.hcml":   {Data: []byte("<page hcmlns=\"staticgen\">\n  <div>\n</page>")},
+	}
+
+	def, err := component.LoadDefinitionFS(fsys, "broken/card.hcml")
+	require.NoError(t, err)
+	err = component.NewRegistry().Register(def)
+	diagErr, ok := diag.As(err)
+	require.True(t, ok)
+	assert.Equal(t, "broken/card.hcml", diagErr.Pos.File)
+	assert.Equal(t, 2, diagErr.Pos.Line)
+
+	_, err = page.LoadPageFS(fsys, "pages/bad.hcml", component.NewRegistry())
+	diagErr, ok = diag.As(err)
+	require.True(t, ok)
+	assert.Equal(t, "pages/bad.hcml", diagErr.Pos.File)
+
+	err = component.NewRegistry().LoadAllFS(fsys, "missing")
+	require.Error(t, err)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestSiteBuildWithLibraryFS(t *testing.T) {
+	dir := t.TempDir()
+	pagesDir := filepath.Join(dir, "pages")
+	require.NoError(t, os.MkdirAll(pagesDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(pagesDir, "index.hcml"), []byte(`<page hcmlns="staticgen" title="Home">
+  <ds:button label="Go" />
+</page>`), 0644))
+
+	result, err := site.Build(site.Options{
+		PagesDir:  pagesDir,
+		OutputDir: filepath.Join(dir, "dist"),
+		LibraryFS: map[string]fs.FS{"ds": designSystem},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Generated)
+
+	data, err := os.ReadFile(filepath.Join(dir, "dist", "index.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "ds-button")
+}
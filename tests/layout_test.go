@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blamarvt/staticgen/pkg/component"
+	"github.com/blamarvt/staticgen/pkg/page"
+	"github.com/blamarvt/staticgen/pkg/site"
+)
+
+func TestPageLayout(t *testing.T) {
+	registry := component.NewRegistry()
+	require.NoError(t, registry.LoadAll("fixtures/components"))
+
+	p, err := page.LoadPage("fixtures/layouts/about.hcml", registry)
+	require.NoError(t, err)
+	assert.Equal(t, "skeleton", p.Layout)
+	assert.Equal(t, []string{"greeting", "skeleton"}, p.ComponentNames(), "The layout is a dependency of the page")
+	assert.Empty(t, p.Params, "layout is not a custom parameter")
+
+	html, err := page.Generate(p, registry, nil)
+	require.NoError(t, err)
+	assert.Contains(t, html, "<title>About Us</title>", "The layout should receive the page title")
+	assert.Contains(t, html, "Hello, Ada!")
+	assert.Contains(t, html, "<p>We build static sites.</p>")
+}
+
+func TestPageLayoutSlots(t *testing.T) {
+	registry := component.NewRegistry()
+	require.NoError(t, registry.LoadAll("fixtures/components"))
+
+	p, err := page.LoadPage("fixtures/layouts/panel.hcml", registry)
+	require.NoError(t, err)
+
+	html, err := page.Generate(p, registry, nil)
+	require.NoError(t, err)
+	assert.Contains(t, html, "Panel Page")
+	assert.Contains(t, html, "<p>Panel body</p>", "Top-level slot elements should fill the layout's slots")
+}
+
+func TestPageLayoutNotFound(t *testing.T) {
+	registry := component.NewRegistry()
+	require.NoError(t, registry.LoadAll("fixtures/components"))
+
+	p, err := page.LoadPage("fixtures/layouts/about.hcml", registry)
+	require.NoError(t, err)
+	p.Layout = "missing"
+
+	_, err = page.Generate(p, registry, nil)
+	require.ErrorIs(t, err, component.ErrNotFound)
+	assert.Contains(t, err.Error(), "fixtures/layouts/about.hcml:1:1: layout component definition not found: missing")
+}
+
+func TestSiteBuildDefaultLayout(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "pages", "index.hcml"), `<page hcmlns="staticgen" title="Home">
+  <p>Welcome home</p>
+</page>`)
+	writeFile(t, filepath.Join(dir, "pages", "raw.hcml"), `<page hcmlns="staticgen" title="Raw" layout="">
+  <p>No layout</p>
+</page>`)
+
+	_, err := site.Build(site.Options{
+		ComponentsDir: "fixtures/components",
+		PagesDir:      filepath.Join(dir, "pages"),
+		OutputDir:     filepath.Join(dir, "dist"),
+		Layout:        "skeleton",
+	})
+	require.NoError(t, err)
+
+	html, err := os.ReadFile(filepath.Join(dir, "dist", "index.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(html), "<title>Home</title>")
+	assert.Contains(t, string(html), "Welcome home")
+
+	raw, err := os.ReadFile(filepath.Join(dir, "dist", "raw.html"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), "<title>", `layout="" should opt out of the default layout`)
+}
+
+func TestSiteBuildLayoutMissingAttribute(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "pages", "index.hcml"), `<page hcmlns="staticgen">
+  <p>Untitled</p>
+</page>`)
+
+	_, err := site.Build(site.Options{
+		ComponentsDir: "fixtures/components",
+		PagesDir:      filepath.Join(dir, "pages"),
+		OutputDir:     filepath.Join(dir, "dist"),
+		Layout:        "skeleton",
+	})
+	var failures site.Errors
+	require.ErrorAs(t, err, &failures)
+	assert.Equal(t, site.ValidationError, failures[0].Kind)
+	assert.Contains(t, err.Error(), `component "skeleton" is missing required attribute "title"`)
+}
+
+func TestSiteBuildDefaultLayoutChange(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "pages", "index.hcml"), `<page hcmlns="staticgen" title="Home">
+  <p>Welcome home</p>
+</page>`)
+
+	opts := site.Options{
+		ComponentsDir: "fixtures/components",
+		PagesDir:      filepath.Join(dir, "pages"),
+		OutputDir:     filepath.Join(dir, "dist"),
+		CacheFile:     filepath.Join(dir, ".staticgen-cache.json"),
+	}
+	_, err := site.Build(opts)
+	require.NoError(t, err)
+
+	// Changing the default layout regenerates pages that use it
+	opts.Layout = "skeleton"
+	result, err := site.Build(opts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Generated)
+
+	html, err := os.ReadFile(filepath.Join(dir, "dist", "index.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(html), "<title>Home</title>")
+
+	// An invalid layout is reported even though the page itself is unchanged
+	opts.Layout = "wrap"
+	_, err = site.Build(opts)
+	assert.ErrorContains(t, err, "layout component definition not found: wrap")
+}
@@ -0,0 +1,192 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blamarvt/staticgen/pkg/component"
+	"github.com/blamarvt/staticgen/pkg/markdown"
+	"github.com/blamarvt/staticgen/pkg/page"
+	"github.com/blamarvt/staticgen/pkg/site"
+)
+
+func TestSplitFrontMatter(t *testing.T) {
+	front, body := markdown.SplitFrontMatter([]byte("---\ntitle: Hi\n---\n# Hi\n"))
+	assert.Equal(t, "title: Hi\n", string(front))
+	assert.Equal(t, "# Hi\n", string(body))
+
+	front, body = markdown.SplitFrontMatter([]byte("# No front matter\n"))
+	assert.Empty(t, front)
+	assert.Equal(t, "# No front matter\n", string(body))
+
+	front, body = markdown.SplitFrontMatter([]byte("---\nAn unclosed rule\n"))
+	assert.Empty(t, front)
+	assert.Equal(t, "---\nAn unclosed rule\n", string(body), "Without a closing delimiter there is no front matter")
+}
+
+func TestLoadMarkdownPage(t *testing.T) {
+	registry := component.NewRegistry()
+	require.NoError(t, registry.LoadAll("fixtures/components"))
+
+	p, err := page.LoadPage("fixtures/markdown/guide.md", registry)
+	require.NoError(t, err)
+	assert.Equal(t, "Getting Started", p.Title)
+	assert.Equal(t, "/docs/getting-started.html", p.Path)
+	assert.Equal(t, "skeleton", p.Layout)
+	assert.Equal(t, []string{"docs", "intro"}, p.Tags)
+	assert.Equal(t, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), p.Date)
+	assert.Equal(t, "writers", p.Param("audience"))
+
+	html, err := page.Generate(p, registry, nil)
+	require.NoError(t, err)
+	assert.Contains(t, html, "<title>Getting Started</title>", "The layout should receive the front matter")
+	assert.Contains(t, html, "<h1>Getting Started</h1>")
+	assert.Contains(t, html, "Install staticgen &amp; run <code>staticgen build</code>")
+	assert.Contains(t, html, "<b>any</b>", "Raw HTML is kept")
+	assert.Contains(t, html, "<table>")
+}
+
+func TestLoadMarkdownPageInvalidFrontMatter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.md")
+	writeFile(t, path, "---\ntitle: [unclosed\n---\nBody\n")
+
+	_, err := page.LoadPage(path, component.NewRegistry())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), path+":1:1: invalid front matter")
+
+	writeFile(t, path, "---\ndraft: maybe\n---\nBody\n")
+	_, err = page.LoadPage(path, component.NewRegistry())
+	assert.ErrorContains(t, err, `invalid draft "maybe"`)
+}
+
+func TestSiteBuildMarkdownPages(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "pages", "docs", "intro.md"), "---\ntitle: Intro\n---\nHello *docs*\n")
+	writeFile(t, filepath.Join(dir, "pages", "index.hcml"), `<page hcmlns="staticgen" title="Home"><p>Home</p></page>`)
+
+	result, err := site.Build(site.Options{
+		ComponentsDir: "fixtures/components",
+		PagesDir:      filepath.Join(dir, "pages"),
+		OutputDir:     filepath.Join(dir, "dist"),
+		Layout:        "skeleton",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Generated)
+
+	html, err := os.ReadFile(filepath.Join(dir, "dist", "docs", "intro.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(html), "<title>Intro</title>")
+	assert.Contains(t, string(html), "Hello <em>docs</em>")
+}
+
+func TestMarkdownComponent(t *testing.T) {
+	registry := component.NewRegistry()
+	p, err := page.LoadPage("fixtures/markdown/embed.hcml", registry)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join("fixtures/markdown", "copy", "launch.md")}, p.Includes)
+	assert.Empty(t, p.ComponentNames(), "markdown is built in")
+
+	html, err := page.Generate(p, registry, nil)
+	require.NoError(t, err)
+	assert.Contains(t, html, "<h1>Pricing &amp; Plans</h1>")
+	assert.Contains(t, html, "Plans start at &lt;$5 a month.")
+	assert.Contains(t, html, "<pre><code>indented code", "Indentation shared with the page is removed")
+	assert.Contains(t, html, "<h2>Launch Week</h2>")
+	assert.Contains(t, html, "<strong>Monday</strong>")
+}
+
+func TestMarkdownComponentErrors(t *testing.T) {
+	dir := t.TempDir()
+	tests := map[string]string{
+		`<component:markdown src="missing.md" />`:                     "reading markdown file",
+		`<component:markdown src="a.md">Text</component:markdown>`:    "markdown takes either a src attribute or text, not both",
+		`<component:markdown lang="en">Text</component:markdown>`:     `markdown has no attribute "lang"`,
+		`<component:markdown>A <b>bold</b> move</component:markdown>`: "markdown can't contain the element <b>",
+	}
+	for body, message := range tests {
+		path := filepath.Join(dir, "page.hcml")
+		writeFile(t, filepath.Join(dir, "a.md"), "# A\n")
+		writeFile(t, path, `<page hcmlns="staticgen" hcmlns:component="staticgen:components">`+body+`</page>`)
+		_, err := page.LoadPage(path, component.NewRegistry())
+		assert.ErrorContains(t, err, message, body)
+	}
+}
+
+func TestMarkdownTemplateFunction(t *testing.T) {
+	registry := component.NewRegistry()
+	require.NoError(t, registry.LoadAll("fixtures/markdown/components"))
+
+	html, err := (&component.Instance{
+		DefinitionName: "lede",
+		Attributes:     map[string]string{"text": "Built with *care*"},
+	}).Render(registry, nil)
+	require.NoError(t, err)
+	assert.Contains(t, html, `<div class="lede"><p>Built with <em>care</em></p>`)
+}
+
+func TestMarkdownTemplateFunctionOmitsRawHTML(t *testing.T) {
+	registry := component.NewRegistry()
+	require.NoError(t, registry.LoadAll("fixtures/markdown/components"))
+
+	html, err := (&component.Instance{
+		DefinitionName: "lede",
+		Attributes:     map[string]string{"text": "Hi <script>alert(1)</script> [x](javascript:alert(1))"},
+	}).Render(registry, nil)
+	require.NoError(t, err)
+	assert.NotContains(t, html, "<script>", "Raw HTML in template text must not reach the output")
+	assert.NotContains(t, html, "javascript:")
+	assert.Contains(t, html, "raw HTML omitted")
+}
+
+func TestSiteBuildRebuildsOnMarkdownInclude(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "pages", "index.hcml"), `<page hcmlns="staticgen" hcmlns:component="staticgen:components">
+  <component:markdown src="../copy/intro.md" />
+</page>`)
+	writeFile(t, filepath.Join(dir, "copy", "intro.md"), "Hello\n")
+
+	opts := site.Options{
+		PagesDir:  filepath.Join(dir, "pages"),
+		OutputDir: filepath.Join(dir, "dist"),
+		CacheFile: filepath.Join(dir, "cache.json"),
+	}
+	_, err := site.Build(opts)
+	require.NoError(t, err)
+
+	time.Sleep(10 * time.Millisecond)
+	writeFile(t, filepath.Join(dir, "copy", "intro.md"), "Goodbye\n")
+	result, err := site.Build(opts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Generated, "Changing an included Markdown file rebuilds the page")
+
+	html, err := os.ReadFile(filepath.Join(dir, "dist", "index.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(html), "Goodbye")
+}
+
+func TestSiteBuildSkipsIncludedMarkdown(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "pages", "index.hcml"), `<page hcmlns="staticgen" hcmlns:component="staticgen:components">
+  <component:markdown src="copy/launch.md" />
+</page>`)
+	writeFile(t, filepath.Join(dir, "pages", "copy", "launch.md"), "## Launch Week\n")
+	writeFile(t, filepath.Join(dir, "pages", "about.md"), "About us\n")
+
+	result, err := site.Build(site.Options{
+		PagesDir:  filepath.Join(dir, "pages"),
+		OutputDir: filepath.Join(dir, "dist"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Generated)
+
+	html, err := os.ReadFile(filepath.Join(dir, "dist", "index.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(html), "Launch Week")
+	assert.NoFileExists(t, filepath.Join(dir, "dist", "copy", "launch.html"), "Included Markdown is not published on its own")
+	assert.FileExists(t, filepath.Join(dir, "dist", "about.html"))
+}
@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blamarvt/staticgen/pkg/component"
+	"github.com/blamarvt/staticgen/pkg/page"
+	"github.com/blamarvt/staticgen/pkg/site"
+)
+
+func TestPageMetadata(t *testing.T) {
+	registry := component.NewRegistry()
+	require.NoError(t, registry.LoadAll("fixtures/metadata"))
+
+	p, err := page.LoadPage("fixtures/metadata/post.hcml", registry)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Hello & Welcome", p.Title)
+	assert.Equal(t, "A first post", p.Description)
+	assert.Equal(t, []string{"go", "static sites"}, p.Tags)
+	assert.True(t, p.HasTag("go"))
+	assert.Equal(t, time.Date(2026, 2, 14, 0, 0, 0, 0, time.UTC), p.Date)
+	assert.False(t, p.Draft)
+	assert.Equal(t, map[string]string{"author": "Sam"}, p.Params, "Only unknown attributes should be params")
+	assert.Equal(t, "Sam", p.Param("author"))
+	assert.Empty(t, p.Param("missing"))
+}
+
+func TestPageMetadataInTemplates(t *testing.T) {
+	registry := component.NewRegistry()
+	require.NoError(t, registry.LoadAll("fixtures/metadata"))
+
+	p, err := page.LoadPage("fixtures/metadata/post.hcml", registry)
+	require.NoError(t, err)
+
+	html, err := page.Generate(p, registry, nil)
+	require.NoError(t, err)
+	assert.Contains(t, html, "<title>Hello &amp; Welcome</title>")
+	assert.Contains(t, html, `<meta name="description" content="A first post">`)
+	assert.Contains(t, html, `<meta name="author" content="Sam">`)
+	assert.Contains(t, html, `<meta name="keywords" content="go,static sites">`)
+	assert.Contains(t, html, `<meta name="date" content="2026-02-14">`)
+}
+
+func TestPageMetadataInvalidDate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "post.hcml")
+	writeFile(t, path, `<page hcmlns="staticgen" date="yesterday"></page>`)
+
+	_, err := page.LoadPage(path, component.NewRegistry())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `post.hcml:1:1: invalid date "yesterday"`)
+}
+
+func TestSiteBuildLeavesOutDrafts(t *testing.T) {
+	dir := copyFixtureSite(t)
+	writeFile(t, filepath.Join(dir, "pages", "upcoming.hcml"), `<page hcmlns="staticgen" title="Soon" draft="true">
+  <p>Not yet</p>
+</page>`)
+
+	opts := site.Options{
+		ComponentsDir: filepath.Join(dir, "components"),
+		PagesDir:      filepath.Join(dir, "pages"),
+		OutputDir:     filepath.Join(dir, "dist"),
+	}
+	result, err := site.Build(opts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Drafts)
+	assert.Equal(t, fixturePageCount(t), result.Generated)
+	assert.NoFileExists(t, filepath.Join(dir, "dist", "upcoming.html"))
+
+	opts.Drafts = true
+	result, err = site.Build(opts)
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Drafts)
+	_, err = os.Stat(filepath.Join(dir, "dist", "upcoming.html"))
+	assert.NoError(t, err, "Drafts should be built on request")
+}
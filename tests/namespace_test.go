@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blamarvt/staticgen/pkg/component"
+	"github.com/blamarvt/staticgen/pkg/diag"
+	"github.com/blamarvt/staticgen/pkg/page"
+)
+
+// libraryRegistry loads the fixture components along with two libraries that
+// both define a button
+func libraryRegistry(t *testing.T) *component.Registry {
+	t.Helper()
+	registry := component.NewRegistry()
+	require.NoError(t, registry.LoadAll("fixtures/components"))
+	require.NoError(t, registry.LoadLibrary("fixtures/libraries/ui", "acme:ui"))
+	require.NoError(t, registry.LoadLibrary("fixtures/libraries/marketing", "marketing"))
+	return registry
+}
+
+func TestLibrariesKeepSameNamedComponentsApart(t *testing.T) {
+	registry := libraryRegistry(t)
+
+	ui := registry.Resolve("acme:ui", "button")
+	require.NotNil(t, ui)
+	assert.Equal(t, "acme:ui", ui.Namespace)
+	assert.Equal(t, "acme:ui:button", ui.QualifiedName())
+
+	marketing := registry.Get("marketing:button")
+	require.NotNil(t, marketing)
+	assert.NotEqual(t, ui.Path, marketing.Path)
+
+	assert.Nil(t, registry.Get("button"), "Library components should not be in the default namespace")
+	assert.Equal(t, component.DefaultNamespace, registry.Get("greeting").Namespace)
+}
+
+func TestPageResolvesLibraryPrefixes(t *testing.T) {
+	registry := libraryRegistry(t)
+
+	p, err := page.LoadPage("fixtures/libraries/page.hcml", registry)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"acme:ui:button", "greeting", "marketing:button"}, p.ComponentNames())
+
+	html, err := page.Generate(p, registry, nil)
+	require.NoError(t, err)
+	assert.Contains(t, html, "Hello, World!")
+	assert.Contains(t, html, `class="ui-button"`)
+	assert.Contains(t, html, `href="/signup"`)
+}
+
+func TestPageRejectsUnknownPrefix(t *testing.T) {
+	registry := libraryRegistry(t)
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "typo.hcml")
+	writeFile(t, path, `<page hcmlns="staticgen">
+  <marketng:button href="/signup">Sign up</marketng:button>
+</page>`)
+	_, err := page.LoadPage(path, registry)
+	diagErr, ok := diag.As(err)
+	require.True(t, ok, "Expected a positioned error, got %v", err)
+	assert.Equal(t, 2, diagErr.Pos.Line)
+	assert.Contains(t, err.Error(), `unknown namespace prefix "marketng"`)
+
+	writeFile(t, path, `<page hcmlns="staticgen" layout="uii:base"></page>`)
+	_, err = page.LoadPage(path, registry)
+	assert.ErrorContains(t, err, `unknown namespace prefix "uii"`)
+
+	// The component prefix names the default namespace even when unbound
+	writeFile(t, path, `<page hcmlns="staticgen"><component:greeting name="Ada" /></page>`)
+	p, err := page.LoadPage(path, registry)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"greeting"}, p.ComponentNames())
+}
+
+func TestPageValidatesLibraryAttributes(t *testing.T) {
+	registry := component.NewRegistry()
+	require.NoError(t, registry.LoadAll("fixtures/components"))
+	require.NoError(t, registry.LoadLibrary("fixtures/libraries/marketing", "acme:ui"))
+	require.NoError(t, registry.LoadLibrary("fixtures/libraries/ui", "marketing"))
+
+	// The libraries are swapped, so each button is given the other's attributes
+	_, err := page.LoadPage("fixtures/libraries/page.hcml", registry)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `component "button" is missing required attribute "href"`)
+}
+
+func TestDuplicateComponentInNamespace(t *testing.T) {
+	registry := component.NewRegistry()
+	err := registry.LoadAll("fixtures/libraries/duplicate")
+	require.Error(t, err)
+
+	diagErr, ok := diag.As(err)
+	require.True(t, ok)
+	assert.Equal(t, "fixtures/libraries/duplicate/cta.hcml", diagErr.Pos.File)
+	assert.Contains(t, err.Error(), "component button in namespace staticgen:components is already defined in fixtures/libraries/duplicate/button.hcml")
+
+	// The same name in another namespace is fine
+	require.NoError(t, registry.LoadLibrary("fixtures/libraries/marketing", "marketing"))
+}
+
+func TestDefinitionDeclaresNamespace(t *testing.T) {
+	registry := component.NewRegistry()
+	require.NoError(t, registry.LoadAll("fixtures/libraries/declared"))
+
+	assert.Nil(t, registry.Get("card"))
+	require.NotNil(t, registry.Resolve("acme:cards", "card"))
+	assert.True(t, registry.HasNamespace("acme:cards"))
+}
@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blamarvt/staticgen/pkg/component"
+	"github.com/blamarvt/staticgen/pkg/page"
+	"github.com/blamarvt/staticgen/pkg/site"
+)
+
+func TestPageForPage(t *testing.T) {
+	registry := component.NewRegistry()
+	require.NoError(t, registry.LoadAll("fixtures/pagination/components"))
+
+	p, err := page.LoadPage("fixtures/pagination/pages/blog/index.hcml", registry)
+	require.NoError(t, err)
+	assert.Equal(t, "posts", p.Paginate)
+	assert.Equal(t, 2, p.PerPage)
+	assert.Empty(t, p.Params, "paginate and per_page are not custom parameters")
+
+	items := []any{"a", "b", "c"}
+	assert.Equal(t, 2, p.Pages(len(items)))
+	assert.Equal(t, 1, p.Pages(0), "An empty list still has a page")
+
+	last := p.ForPage(items, page.Paginator{Page: 2, Total: 2})
+	assert.Equal(t, []any{"c"}, last.Paginator.Items)
+	assert.Equal(t, 3, last.Paginator.TotalItems)
+	assert.Nil(t, p.Paginator, "The paginated page itself is unchanged")
+}
+
+func TestPaginationAttributes(t *testing.T) {
+	dir := t.TempDir()
+	tests := map[string]string{
+		`<page hcmlns="staticgen" paginate="posts" per_page="0"></page>`:                      `invalid per_page "0": expected a positive number`,
+		`<page hcmlns="staticgen" per_page="5"></page>`:                                       "per_page needs a list to paginate",
+		`<page hcmlns="staticgen" paginate="posts" collection="posts" path="/{slug}"></page>`: "a page can't be both a collection and paginated",
+	}
+	for source, message := range tests {
+		path := filepath.Join(dir, "page.hcml")
+		writeFile(t, path, source)
+		_, err := page.LoadPage(path, component.NewRegistry())
+		assert.ErrorContains(t, err, message, source)
+	}
+
+	writeFile(t, filepath.Join(dir, "page.hcml"), `<page hcmlns="staticgen" paginate="posts"></page>`)
+	p, err := page.LoadPage(filepath.Join(dir, "page.hcml"), component.NewRegistry())
+	require.NoError(t, err)
+	assert.Equal(t, page.DefaultPerPage, p.PerPage)
+}
+
+func TestSiteBuildPagination(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "data", "posts.yml"), "- title: Five\n- title: Four\n- title: Three\n- title: Two\n- title: One\n")
+
+	result, err := site.Build(site.Options{
+		ComponentsDir: "fixtures/pagination/components",
+		PagesDir:      "fixtures/pagination/pages",
+		OutputDir:     filepath.Join(dir, "dist"),
+		DataDir:       filepath.Join(dir, "data"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Generated)
+
+	read := func(path string) string {
+		html, err := os.ReadFile(filepath.Join(dir, "dist", filepath.FromSlash(path)))
+		require.NoError(t, err)
+		return string(html)
+	}
+
+	first := read("blog/index.html")
+	assert.Contains(t, first, "Page 1 of 3")
+	assert.Contains(t, first, "<li>Five")
+	assert.Contains(t, first, "<li>Four")
+	assert.NotContains(t, first, "Three")
+	assert.NotContains(t, first, `class="prev"`)
+	assert.Contains(t, first, `<a class="next" href="/blog/page/2/">Older</a>`)
+
+	second := read("blog/page/2/index.html")
+	assert.Contains(t, second, "Page 2 of 3")
+	assert.Contains(t, second, "<li>Three")
+	assert.Contains(t, second, `<a class="prev" href="/blog/">Newer</a>`)
+	assert.Contains(t, second, `<a class="next" href="/blog/page/3/">Older</a>`)
+
+	last := read("blog/page/3/index.html")
+	assert.Contains(t, last, "<li>One")
+	assert.NotContains(t, last, `class="next"`)
+}
@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blamarvt/staticgen/pkg/component"
+	"github.com/blamarvt/staticgen/pkg/htmlutil"
+	"github.com/blamarvt/staticgen/pkg/page"
+	"github.com/blamarvt/staticgen/pkg/vars"
+)
+
+func TestComponentSchemaLoaded(t *testing.T) {
+	registry := component.NewRegistry()
+	err := registry.LoadAll("fixtures/components")
+	require.NoError(t, err, "Failed to load component definitions")
+
+	greeting := registry.Get("greeting")
+	require.NotNil(t, greeting)
+
+	assert.Equal(t, []string{"name"}, greeting.RequiredAttrs)
+	assert.Equal(t, []string{"message"}, greeting.OptionalAttrs)
+	assert.NotContains(t, greeting.Template, "<attribute", "Schema declarations should not be part of the template")
+
+	footer := registry.Get("footer")
+	require.NotNil(t, footer)
+	assert.False(t, footer.HasSchema(), "Footer declares no attributes")
+}
+
+func TestComponentSchemaUnknownAttribute(t *testing.T) {
+	registry := component.NewRegistry()
+	err := registry.LoadAll("fixtures/components")
+	require.NoError(t, err, "Failed to load component definitions")
+
+	_, err = page.LoadPage("fixtures/invalid/unknown-attr.hcml", registry)
+	require.Error(t, err, "Expected an error for an unknown attribute")
+	assert.Contains(t, err.Error(), "fixtures/invalid/unknown-attr.hcml")
+	assert.Contains(t, err.Error(), `component "greeting" has unknown attribute "nmae"`)
+	assert.Contains(t, err.Error(), `component "greeting" is missing required attribute "name"`)
+
+	var attrErr *component.AttributeError
+	require.True(t, errors.As(err, &attrErr), "Error should wrap an AttributeError")
+	assert.Equal(t, "greeting", attrErr.Component)
+}
+
+func TestComponentSchemaMissingAttribute(t *testing.T) {
+	registry := component.NewRegistry()
+	err := registry.LoadAll("fixtures/components")
+	require.NoError(t, err, "Failed to load component definitions")
+
+	_, err = page.LoadPage("fixtures/invalid/missing-attr.hcml", registry)
+	require.Error(t, err, "Expected an error for a missing attribute")
+	assert.Contains(t, err.Error(), "fixtures/invalid/missing-attr.hcml")
+	assert.Contains(t, err.Error(), `component "skeleton" is missing required attribute "title"`)
+}
+
+func TestComponentSchemaUnregisteredComponent(t *testing.T) {
+	// Without definitions there is nothing to validate against, so loading
+	// succeeds and the missing component is reported at generation time
+	registry := component.NewRegistry()
+
+	_, err := page.LoadPage("fixtures/invalid/unknown-attr.hcml", registry)
+	assert.NoError(t, err)
+}
+
+func TestComponentAttributeDefaults(t *testing.T) {
+	registry := component.NewRegistry()
+	err := registry.LoadAll("fixtures/components")
+	require.NoError(t, err, "Failed to load component definitions")
+
+	greeting := registry.Get("greeting")
+	require.NotNil(t, greeting)
+	assert.Equal(t, map[string]string{"message": "Nice to meet you!"}, greeting.Defaults)
+
+	p, err := page.LoadPage("fixtures/pages/defaults.hcml", registry)
+	require.NoError(t, err, "Failed to load page")
+
+	html, err := page.Generate(p, registry, vars.NewStore())
+	require.NoError(t, err, "Failed to generate HTML")
+
+	assert.Equal(
+		t,
+		htmlutil.MustNormalize(`
+			<!DOCTYPE html>
+			<html>
+				<head>
+					<title>Defaults Test</title>
+				</head>
+				<body>
+					<div class="greeting">
+						<h1>Hello, World!</h1>
+						<p>Nice to meet you!</p>
+					</div>
+					<div class="greeting">
+						<h1>Hello, Friend!</h1>
+						<p>Explicit values win.</p>
+					</div>
+				</body>
+			</html>
+		`),
+		htmlutil.MustNormalize(html),
+	)
+}
@@ -0,0 +1,295 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blamarvt/staticgen/pkg/site"
+)
+
+func TestSiteBuild(t *testing.T) {
+	outputDir := t.TempDir()
+
+	_, err := site.Build(site.Options{
+		ComponentsDir: "fixtures/components",
+		PagesDir:      "fixtures/pages",
+		OutputDir:     outputDir,
+		ConfigFile:    "../.staticgen.yml",
+	})
+	require.NoError(t, err, "Failed to build site")
+
+	for _, name := range []string{"test.html", "nested.html", "mixed.html", "variables.html", "defaults.html", "slots.html", "panels.html"} {
+		data, err := os.ReadFile(filepath.Join(outputDir, name))
+		require.NoError(t, err, "Expected %s to be generated", name)
+		assert.Contains(t, string(data), "<!DOCTYPE html>")
+	}
+
+	data, err := os.ReadFile(filepath.Join(outputDir, "variables.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "My Static Site", "Config variables should be rendered")
+}
+
+// fixturePageCount returns the number of pages in the fixtures directory
+func fixturePageCount(t *testing.T) int {
+	t.Helper()
+	pages, err := filepath.Glob("fixtures/pages/*.hcml")
+	require.NoError(t, err)
+	return len(pages)
+}
+
+// copyFixtureSite copies the fixture components and pages into a fresh
+// directory so tests can modify them
+func copyFixtureSite(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.CopyFS(filepath.Join(dir, "components"), os.DirFS("fixtures/components")))
+	require.NoError(t, os.CopyFS(filepath.Join(dir, "pages"), os.DirFS("fixtures/pages")))
+	return dir
+}
+
+func TestSiteBuildIncremental(t *testing.T) {
+	dir := copyFixtureSite(t)
+	configFile := filepath.Join(dir, ".staticgen.yml")
+	require.NoError(t, os.WriteFile(configFile, []byte("variables:\n  siteName: \"First Name\"\n"), 0644))
+
+	opts := site.Options{
+		ComponentsDir: filepath.Join(dir, "components"),
+		PagesDir:      filepath.Join(dir, "pages"),
+		OutputDir:     filepath.Join(dir, "dist"),
+		ConfigFile:    configFile,
+		CacheFile:     filepath.Join(dir, ".staticgen-cache.json"),
+	}
+
+	result, err := site.Build(opts)
+	require.NoError(t, err, "Failed to build site")
+	assert.Equal(t, fixturePageCount(t), result.Generated)
+	assert.Equal(t, 0, result.Unchanged)
+
+	// Nothing changed, so nothing is regenerated
+	result, err = site.Build(opts)
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Generated)
+	assert.Equal(t, fixturePageCount(t), result.Unchanged)
+
+	// Only variables.html uses the footer component
+	footer := filepath.Join(dir, "components", "footer.hcml")
+	data, err := os.ReadFile(footer)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(footer, []byte(strings.Replace(string(data), "All rights reserved.", "Some rights reserved.", 1)), 0644))
+
+	result, err = site.Build(opts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Generated)
+	assert.Equal(t, fixturePageCount(t)-1, result.Unchanged)
+
+	output, err := os.ReadFile(filepath.Join(dir, "dist", "variables.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(output), "Some rights reserved.")
+
+	// Only the footer reads the siteName variable
+	require.NoError(t, os.WriteFile(configFile, []byte("variables:\n  siteName: \"Second Name\"\n"), 0644))
+	result, err = site.Build(opts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Generated)
+
+	// Deleted output is regenerated even though its inputs are unchanged
+	require.NoError(t, os.Remove(filepath.Join(dir, "dist", "test.html")))
+	result, err = site.Build(opts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Generated)
+
+	// Force ignores the cache entirely
+	opts.Force = true
+	result, err = site.Build(opts)
+	require.NoError(t, err)
+	assert.Equal(t, fixturePageCount(t), result.Generated)
+}
+
+func TestSiteBuildOutputFromDifferentSource(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "pages", "foo.md"), "Top level")
+	writeFile(t, filepath.Join(dir, "pages", "sub", "foo.md"), "Nested")
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "components"), 0755))
+
+	opts := site.Options{
+		ComponentsDir: filepath.Join(dir, "components"),
+		PagesDir:      filepath.Join(dir, "pages"),
+		OutputDir:     filepath.Join(dir, "dist"),
+		CacheFile:     filepath.Join(dir, ".staticgen-cache.json"),
+	}
+	_, err := site.Build(opts)
+	require.NoError(t, err)
+
+	// The nested page now lands on the output the top level page was cached for
+	opts.PagesDir = filepath.Join(dir, "pages", "sub")
+	result, err := site.Build(opts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Generated)
+	assert.Equal(t, 0, result.Unchanged)
+
+	output, err := os.ReadFile(filepath.Join(dir, "dist", "foo.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(output), "Nested")
+}
+
+func TestSiteBuildDeterministicAcrossWorkers(t *testing.T) {
+	build := func(jobs int) string {
+		outputDir := t.TempDir()
+		_, err := site.Build(site.Options{
+			ComponentsDir: "fixtures/components",
+			PagesDir:      "fixtures/pages",
+			OutputDir:     outputDir,
+			ConfigFile:    "../.staticgen.yml",
+			Jobs:          jobs,
+		})
+		require.NoError(t, err, "Failed to build site with %d workers", jobs)
+		return outputDir
+	}
+
+	serial := build(1)
+	concurrent := build(8)
+
+	entries, err := os.ReadDir(serial)
+	require.NoError(t, err)
+	require.NotEmpty(t, entries)
+	for _, entry := range entries {
+		expected, err := os.ReadFile(filepath.Join(serial, entry.Name()))
+		require.NoError(t, err)
+		actual, err := os.ReadFile(filepath.Join(concurrent, entry.Name()))
+		require.NoError(t, err)
+		assert.Equal(t, string(expected), string(actual), "Output of %s differs between worker counts", entry.Name())
+	}
+}
+
+func TestSiteBuildCollectsAllErrors(t *testing.T) {
+	dir := copyFixtureSite(t)
+	require.NoError(t, os.CopyFS(filepath.Join(dir, "pages", "invalid"), os.DirFS("fixtures/invalid")))
+
+	result, err := site.Build(site.Options{
+		ComponentsDir: filepath.Join(dir, "components"),
+		PagesDir:      filepath.Join(dir, "pages"),
+		OutputDir:     filepath.Join(dir, "dist"),
+	})
+	require.Error(t, err)
+
+	var failures site.Errors
+	require.ErrorAs(t, err, &failures)
+	assert.Len(t, failures, 4, "Every invalid page should be reported")
+	assert.Contains(t, err.Error(), "missing-attr.hcml")
+	assert.Contains(t, err.Error(), "unknown-attr.hcml")
+	assert.Contains(t, err.Error(), "slot-attr.hcml")
+
+	// Valid pages are still generated
+	require.NotNil(t, result)
+	assert.Equal(t, fixturePageCount(t), result.Generated)
+	assert.FileExists(t, filepath.Join(dir, "dist", "test.html"))
+}
+
+func TestSiteBuildSummarizesErrors(t *testing.T) {
+	dir := copyFixtureSite(t)
+	require.NoError(t, os.CopyFS(filepath.Join(dir, "pages", "invalid"), os.DirFS("fixtures/invalid")))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "pages", "errors"), 0755))
+	for _, name := range []string{"faulty.hcml", "malformed.hcml"} {
+		data, err := os.ReadFile(filepath.Join("fixtures/errors", name))
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "pages", "errors", name), data, 0644))
+	}
+
+	result, err := site.Build(site.Options{
+		ComponentsDir: filepath.Join(dir, "components"),
+		PagesDir:      filepath.Join(dir, "pages"),
+		OutputDir:     filepath.Join(dir, "dist"),
+	})
+	var failures site.Errors
+	require.ErrorAs(t, err, &failures)
+
+	assert.Equal(t, map[site.ErrorKind]int{
+		site.ParseError:       1,
+		site.ValidationError:  4,
+		site.MissingComponent: 1,
+	}, failures.Counts())
+	assert.Equal(t, "6 errors (1 parse, 4 validation, 1 missing component)", failures.Summary())
+	assert.Equal(t, 6, result.Failed)
+	assert.Equal(t, fixturePageCount(t), result.Generated)
+	assert.Equal(t, fixturePageCount(t)+6, result.Total())
+}
+
+func TestSiteBuildFailFast(t *testing.T) {
+	dir := copyFixtureSite(t)
+	require.NoError(t, os.CopyFS(filepath.Join(dir, "pages", "invalid"), os.DirFS("fixtures/invalid")))
+
+	result, err := site.Build(site.Options{
+		ComponentsDir: filepath.Join(dir, "components"),
+		PagesDir:      filepath.Join(dir, "pages"),
+		OutputDir:     filepath.Join(dir, "dist"),
+		Jobs:          1,
+		FailFast:      true,
+	})
+	var failures site.Errors
+	require.ErrorAs(t, err, &failures)
+
+	// With one worker pages are loaded in order, so only the first invalid
+	// page is attempted and nothing is written
+	require.Len(t, failures, 1)
+	assert.Contains(t, failures[0].Source, "missing-attr.hcml")
+	assert.Equal(t, 1, result.Failed)
+	assert.Equal(t, 0, result.Generated)
+	assert.Equal(t, fixturePageCount(t)+3, result.Skipped)
+	assert.NoDirExists(t, filepath.Join(dir, "dist"))
+}
+
+func TestLoadOptionsDefaults(t *testing.T) {
+	opts, err := site.LoadOptions(filepath.Join(t.TempDir(), ".staticgen.yml"))
+	require.NoError(t, err, "A missing config file should fall back to the defaults")
+
+	assert.Equal(t, []string{"templates"}, opts.ComponentsDirs)
+	assert.Equal(t, "pages", opts.PagesDir)
+	assert.Equal(t, "dist", opts.OutputDir)
+	assert.Equal(t, "static", opts.StaticDir)
+	assert.Equal(t, []string{".hcml", ".md"}, opts.PageExtensions)
+}
+
+func TestSiteBuildConfiguredLayout(t *testing.T) {
+	dir := t.TempDir()
+	blogDir := filepath.Join(dir, "sites", "blog")
+
+	// Components are split across two directories and pages use their own extension
+	for _, name := range []string{"greeting.hcml", "container.hcml", "skeleton.hcml", "footer.hcml"} {
+		sub := "components"
+		if name == "footer.hcml" {
+			sub = "shared"
+		}
+		data, err := os.ReadFile(filepath.Join("fixtures/components", name))
+		require.NoError(t, err)
+		require.NoError(t, os.MkdirAll(filepath.Join(blogDir, sub), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(blogDir, sub, strings.TrimSuffix(name, ".hcml")+".comp"), data, 0644))
+	}
+	data, err := os.ReadFile("fixtures/pages/nested.hcml")
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(blogDir, "content"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(blogDir, "content", "nested.page"), data, 0644))
+
+	config := `components: [components, shared]
+pages: content
+output: public
+page_extensions: page
+component_extensions: [comp]
+`
+	configFile := filepath.Join(blogDir, "site.yml")
+	require.NoError(t, os.WriteFile(configFile, []byte(config), 0644))
+
+	opts, err := site.LoadOptions(configFile)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(blogDir, "components"), filepath.Join(blogDir, "shared")}, opts.ComponentsDirs)
+	assert.Equal(t, filepath.Join(blogDir, "public"), opts.OutputDir)
+
+	result, err := site.Build(opts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Generated)
+	assert.FileExists(t, filepath.Join(blogDir, "public", "nested.html"))
+}
@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blamarvt/staticgen/pkg/component"
+	"github.com/blamarvt/staticgen/pkg/page"
+	"github.com/blamarvt/staticgen/pkg/site"
+)
+
+func TestSiteBuildWritesSitemap(t *testing.T) {
+	dir := copyFixtureSite(t)
+	writeFile(t, filepath.Join(dir, "pages", "blog", "index.hcml"), `<page hcmlns="staticgen" title="Blog" lastmod="2026-03-01">
+  <p>Posts</p>
+</page>`)
+	writeFile(t, filepath.Join(dir, "pages", "drafts.hcml"), `<page hcmlns="staticgen" title="Drafts" sitemap="false">
+  <p>Secret</p>
+</page>`)
+
+	opts := site.Options{
+		ComponentsDir: filepath.Join(dir, "components"),
+		PagesDir:      filepath.Join(dir, "pages"),
+		OutputDir:     filepath.Join(dir, "dist"),
+		BaseURL:       "https://example.com/",
+		Sitemap:       true,
+		Robots:        true,
+	}
+	_, err := site.Build(opts)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(filepath.Join(dir, "dist", "sitemap.xml"))
+	require.NoError(t, err)
+	sitemap := string(data)
+
+	assert.Contains(t, sitemap, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+	assert.Contains(t, sitemap, "<loc>https://example.com/blog/</loc>\n    <lastmod>2026-03-01</lastmod>")
+	assert.Contains(t, sitemap, "<loc>https://example.com/test.html</loc>")
+	assert.Regexp(t, `<loc>https://example.com/nested.html</loc>\s*<lastmod>\d{4}-\d\d-\d\dT`, sitemap, "lastmod should fall back to the source modification time")
+	assert.NotContains(t, sitemap, "drafts")
+
+	robots, err := os.ReadFile(filepath.Join(dir, "dist", "robots.txt"))
+	require.NoError(t, err)
+	assert.Contains(t, string(robots), "Sitemap: https://example.com/sitemap.xml")
+
+	// The output doesn't depend on the build
+	_, err = site.Build(opts)
+	require.NoError(t, err)
+	again, err := os.ReadFile(filepath.Join(dir, "dist", "sitemap.xml"))
+	require.NoError(t, err)
+	assert.Equal(t, sitemap, string(again))
+}
+
+func TestSitemapRequiresBaseURL(t *testing.T) {
+	dir := copyFixtureSite(t)
+
+	_, err := site.Build(site.Options{
+		ComponentsDir: filepath.Join(dir, "components"),
+		PagesDir:      filepath.Join(dir, "pages"),
+		OutputDir:     filepath.Join(dir, "dist"),
+		BaseURL:       "example.com",
+		Sitemap:       true,
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "must be an absolute URL")
+}
+
+func TestPageSitemapAttributes(t *testing.T) {
+	registry := component.NewRegistry()
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "bad.hcml")
+	writeFile(t, path, `<page hcmlns="staticgen" lastmod="March 1st"></page>`)
+	_, err := page.LoadPage(path, registry)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `bad.hcml:1:1: invalid lastmod "March 1st"`)
+
+	writeFile(t, path, `<page hcmlns="staticgen" lastmod="2026-03-01T10:00:00+02:00" sitemap="false"></page>`)
+	p, err := page.LoadPage(path, registry)
+	require.NoError(t, err)
+	assert.Equal(t, "2026-03-01T10:00:00+02:00", p.LastMod)
+	assert.True(t, p.NoSitemap)
+}
@@ -0,0 +1,158 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blamarvt/staticgen/pkg/component"
+	"github.com/blamarvt/staticgen/pkg/htmlutil"
+	"github.com/blamarvt/staticgen/pkg/page"
+	"github.com/blamarvt/staticgen/pkg/vars"
+)
+
+func TestComponentsInsideSlots(t *testing.T) {
+	registry := component.NewRegistry()
+	err := registry.LoadAll("fixtures/components")
+	require.NoError(t, err, "Failed to load component definitions")
+
+	p, err := page.LoadPage("fixtures/pages/slots.hcml", registry)
+	require.NoError(t, err, "Failed to load page")
+
+	// The greeting inside the slot counts as a dependency of the page
+	assert.Equal(t, []string{"container", "greeting", "skeleton"}, p.ComponentNames())
+
+	html, err := page.Generate(p, registry, vars.NewStore())
+	require.NoError(t, err, "Failed to generate HTML")
+
+	assert.Equal(
+		t,
+		htmlutil.MustNormalize(`
+			<!DOCTYPE html>
+			<html>
+				<head>
+					<title>Slots Test</title>
+				</head>
+				<body>
+					<div class="container">
+						<h2>Slotted Components</h2>
+						<p>Main content</p>
+						<div class="extra-content">
+							<div class="greeting">
+								<h1>Hello, Slot!</h1>
+								<p>Rendered inside a slot!</p>
+							</div>
+							<p>Plain HTML next to it.</p>
+						</div>
+					</div>
+				</body>
+			</html>
+		`),
+		htmlutil.MustNormalize(html),
+	)
+}
+
+func TestSlotKeepsTextAroundElements(t *testing.T) {
+	registry := component.NewRegistry()
+	require.NoError(t, registry.LoadAll("fixtures/components"))
+
+	p, err := page.LoadPage("fixtures/pages/slottext.hcml", registry)
+	require.NoError(t, err)
+
+	html, err := page.Generate(p, registry, vars.NewStore())
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		htmlutil.MustNormalize(`
+			<div class="container">
+				<h2>Mixed</h2>
+				<div class="extra-content">
+					Hello <b>world</b> and
+					<div class="greeting">
+						<h1>Hello, Ada!</h1>
+						<p>Hi</p>
+					</div>
+					bye
+				</div>
+			</div>
+		`),
+		htmlutil.MustNormalize(html),
+		"Text between elements in a slot should be kept",
+	)
+}
+
+func TestSlotComponentsAreValidated(t *testing.T) {
+	registry := component.NewRegistry()
+	err := registry.LoadAll("fixtures/components")
+	require.NoError(t, err, "Failed to load component definitions")
+
+	_, err = page.LoadPage("fixtures/invalid/slot-attr.hcml", registry)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `component "greeting" is missing required attribute "name"`)
+}
+
+func TestSlotDeclarations(t *testing.T) {
+	registry := component.NewRegistry()
+	err := registry.LoadAll("fixtures/components")
+	require.NoError(t, err, "Failed to load component definitions")
+
+	panel := registry.Get("panel")
+	require.NotNil(t, panel)
+	assert.Equal(t, []string{"body"}, panel.RequiredSlots)
+	assert.Equal(t, []string{"footer"}, panel.OptionalSlots)
+	assert.Equal(t, `<p class="panel-footer">No footer provided.</p>`, panel.SlotFallbacks["footer"])
+	assert.NotContains(t, panel.Template, "<slot", "Slot declarations should not be part of the template")
+}
+
+func TestSlotFallbackContent(t *testing.T) {
+	registry := component.NewRegistry()
+	err := registry.LoadAll("fixtures/components")
+	require.NoError(t, err, "Failed to load component definitions")
+
+	p, err := page.LoadPage("fixtures/pages/panels.hcml", registry)
+	require.NoError(t, err, "Failed to load page")
+
+	html, err := page.Generate(p, registry, vars.NewStore())
+	require.NoError(t, err, "Failed to generate HTML")
+
+	assert.Equal(
+		t,
+		htmlutil.MustNormalize(`
+			<!DOCTYPE html>
+			<html>
+				<head>
+					<title>Panels Test</title>
+				</head>
+				<body>
+					<section class="panel">
+						<h3>With Footer</h3>
+						<p>First panel body.</p>
+						<p>Custom footer.</p>
+					</section>
+					<section class="panel">
+						<h3>Without Footer</h3>
+						<p>Second panel body.</p>
+						<p class="panel-footer">No footer provided.</p>
+					</section>
+				</body>
+			</html>
+		`),
+		htmlutil.MustNormalize(html),
+	)
+}
+
+func TestRequiredSlotMissing(t *testing.T) {
+	registry := component.NewRegistry()
+	err := registry.LoadAll("fixtures/components")
+	require.NoError(t, err, "Failed to load component definitions")
+
+	_, err = page.LoadPage("fixtures/invalid/missing-slot.hcml", registry)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "fixtures/invalid/missing-slot.hcml")
+	assert.Contains(t, err.Error(), `component "panel" is missing required slot "body"`)
+	assert.Contains(t, err.Error(), `component "panel" has unknown slot "sidebar"`)
+
+	var slotErr *component.SlotError
+	assert.ErrorAs(t, err, &slotErr)
+}
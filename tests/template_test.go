@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blamarvt/staticgen/pkg/component"
+	"github.com/blamarvt/staticgen/pkg/page"
+	"github.com/blamarvt/staticgen/pkg/vars"
+)
+
+func TestRegistryRejectsBrokenTemplate(t *testing.T) {
+	// Syntax errors surface when the component is loaded, even if no page uses it
+	registry := component.NewRegistry()
+	err := registry.LoadAll("fixtures/broken")
+	require.Error(t, err, "Expected broken template to fail at load time")
+	assert.Contains(t, err.Error(), "broken.hcml")
+	assert.Contains(t, err.Error(), "failed to parse template for broken")
+	assert.Nil(t, registry.Get("broken"), "Broken definitions should not be registered")
+}
+
+func TestRegisterCompilesTemplate(t *testing.T) {
+	registry := component.NewRegistry()
+
+	err := registry.Register(&component.Definition{Name: "bad", Template: "{{ if .X }}unterminated"})
+	assert.Error(t, err)
+
+	err = registry.Register(&component.Definition{Name: "good", Template: "<p>{{ .Text }}</p>"})
+	assert.NoError(t, err)
+}
+
+func TestCompiledTemplateReusedAcrossRenders(t *testing.T) {
+	registry := component.NewRegistry()
+	err := registry.LoadAll("fixtures/components")
+	require.NoError(t, err, "Failed to load component definitions")
+
+	p, err := page.LoadPage("fixtures/pages/defaults.hcml", registry)
+	require.NoError(t, err, "Failed to load page")
+
+	// Rendering repeatedly must not leak state between executions of the same template
+	first, err := page.Generate(p, registry, vars.NewStore())
+	require.NoError(t, err)
+	for range 10 {
+		html, err := page.Generate(p, registry, vars.NewStore())
+		require.NoError(t, err)
+		assert.Equal(t, first, html)
+	}
+}
+
+func TestRenderUsesTemplateCompiledAtRegistration(t *testing.T) {
+	registry := component.NewRegistry()
+	require.NoError(t, registry.LoadAll("fixtures/components"))
+
+	// Breaking the template text after registration must not affect rendering,
+	// since the template was parsed once when the component was registered
+	def := registry.Get("greeting")
+	require.NotNil(t, def)
+	def.Template = "{{ if .Name }}unterminated"
+
+	html, err := (&component.Instance{
+		DefinitionName: "greeting",
+		Attributes:     map[string]string{"name": "World", "message": "Hi"},
+	}).Render(registry, nil)
+	require.NoError(t, err, "Rendering should use the compiled template, not re-parse Template")
+	assert.Contains(t, html, "Hello, World!")
+}
@@ -54,6 +54,35 @@ func TestVariableStore(t *testing.T) {
 	assert.Equal(t, "default", store.GetOrDefault("nonexistent", "default"))
 }
 
+func TestVariableStoreZeroValue(t *testing.T) {
+	var store vars.Store
+	_, ok := store.Get("key")
+	assert.False(t, ok, "A zero Store should be empty")
+
+	store.Set("key", "value")
+	assert.Equal(t, "value", store.GetOrDefault("key", ""))
+	assert.Equal(t, "value", store.Track().GetOrDefault("key", ""), "Tracking views share the variables")
+}
+
+func TestVariableStoreTrack(t *testing.T) {
+	store := vars.NewStore()
+	store.Set("siteName", "My Site")
+	store.Set("year", "2026")
+
+	tracked := store.Track()
+	assert.Equal(t, "My Site", tracked.GetOrDefault("siteName", ""))
+	_, ok := tracked.Get("missing")
+	assert.False(t, ok)
+
+	// Reads through the view are recorded, including missing keys
+	assert.Equal(t, []string{"missing", "siteName"}, tracked.Accessed())
+	assert.Empty(t, store.Accessed(), "The original store does not track reads")
+
+	// The view shares variables with the original store
+	store.Set("year", "2027")
+	assert.Equal(t, "2027", tracked.GetOrDefault("year", ""))
+}
+
 func TestVariablesInTemplate(t *testing.T) {
 	// Create registry and load component definitions (including footer)
 	registry := component.NewRegistry()